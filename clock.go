@@ -0,0 +1,74 @@
+package volcano
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// SkewThreshold 本地时间与服务端时间的偏差超过该值时修正签名时间；Date header精度为秒，较小的偏差会被忽略
+const SkewThreshold = 10 * time.Second
+
+// timeErrorCodes 签名时间与服务端时间偏差过大时返回的错误码
+var timeErrorCodes = map[string]bool{
+	"InvalidTimestamp": true,
+	"SignatureExpired": true,
+	"RequestExpired":   true,
+}
+
+// IsClockSkewed 判断错误是否由签名时间与服务端时间偏差过大导致
+func IsClockSkewed(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && timeErrorCodes[e.Code]
+}
+
+// clock 根据服务端返回的Date header维护本地时间的偏移，用于签名时间
+type clock struct {
+	offset atomic.Int64
+}
+
+// Offset 服务端时间减去本地时间，nil时为0
+func (c *clock) Offset() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return time.Duration(c.offset.Load())
+}
+
+// now 修正后的当前时间
+func (c *clock) now() time.Time {
+	return now().Add(c.Offset())
+}
+
+// observe 使用服务端的Date header更新偏移，sent、received为请求发送和收到返回的本地时间；返回当前偏移以及偏移是否变化
+func (c *clock) observe(date string, sent, received time.Time) (time.Duration, bool) {
+	if c == nil || date == "" {
+		return 0, false
+	}
+	server, err := http.ParseTime(date)
+	if err != nil {
+		return 0, false
+	}
+
+	// Date精度为秒，取区间中点；本地时间取请求往返的中点
+	skew := server.Add(500 * time.Millisecond).Sub(sent.Add(received.Sub(sent) / 2))
+	if skew < SkewThreshold && skew > -SkewThreshold {
+		skew = 0
+	}
+
+	old := c.Offset()
+	if old == skew || (old != 0 && skew != 0 && absDuration(old-skew) < SkewThreshold) {
+		// 偏移变化较小时保留原值，避免Date精度导致频繁变化
+		return old, false
+	}
+	c.offset.Store(int64(skew))
+	return skew, true
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
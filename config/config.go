@@ -0,0 +1,331 @@
+// Package config 从YAML/JSON文件和环境变量加载SDK配置，并生成各服务的客户端
+//
+// 配置文件格式如下，各服务未配置的密钥、OpenApi地址使用顶层配置：
+//
+//	access_key: AK
+//	secret_key: SK
+//	rate_limit:
+//	  default: {qps: 10, burst: 10}
+//	  max_streams: 50
+//	sami:
+//	  app_key: APPKEY
+//	tts:
+//	  access_token: TOKEN
+//	  app_id: APPID
+//	mega:
+//	  access_token: TOKEN
+//	  app_id: APPID
+//	sts: {}
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/openspeech/mega"
+	"github.com/jyinz/volcano-sdk/openspeech/tts"
+	"github.com/jyinz/volcano-sdk/sami/vc"
+	"github.com/jyinz/volcano-sdk/sts"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 覆盖配置文件的环境变量，密钥使用 volcano.EnvAccessKey、volcano.EnvSecretKey、volcano.EnvSessionToken
+const (
+	EnvSamiAppKey      = "VOLC_SAMI_APPKEY"
+	EnvTTSAccessToken  = "VOLC_TTS_ACCESS_TOKEN"
+	EnvTTSAppID        = "VOLC_TTS_APPID"
+	EnvMegaAccessToken = "VOLC_MEGA_ACCESS_TOKEN"
+	EnvMegaAppID       = "VOLC_MEGA_APPID"
+)
+
+// 配置文件格式
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// RateLimit 所有客户端共享的限流配置
+type RateLimit struct {
+	Default    volcano.Rate            `json:"default" yaml:"default"`
+	Rates      map[string]volcano.Rate `json:"rates" yaml:"rates"` // key为 service/action
+	MaxStreams int                     `json:"max_streams" yaml:"max_streams"`
+}
+
+// Config 配置文件内容，服务配置为空时不生成对应的客户端
+type Config struct {
+	volcano.Config `yaml:",inline"`
+
+	RateLimit *RateLimit `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+
+	SAMI *vc.Config      `json:"sami,omitempty" yaml:"sami,omitempty"`
+	TTS  *tts.Config     `json:"tts,omitempty" yaml:"tts,omitempty"`
+	Mega *mega.Config    `json:"mega,omitempty" yaml:"mega,omitempty"`
+	STS  *volcano.Config `json:"sts,omitempty" yaml:"sts,omitempty"`
+}
+
+// Clients 根据配置生成的客户端，未配置的服务为nil
+type Clients struct {
+	VoiceConversion *vc.VoiceConversion
+	TTS             *tts.TTS
+	Mega            *mega.OpenSpeech
+	STS             *sts.STS
+}
+
+// Load 读取配置文件，按扩展名(.yaml/.yml/.json)解析，再使用环境变量覆盖并校验
+func Load(filename string) (*Config, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read config failed: %w", err)
+	}
+
+	var format string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		format = FormatYAML
+	case ".json":
+		format = FormatJSON
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", filename)
+	}
+
+	cfg, err := Parse(b, format)
+	if err != nil {
+		return nil, fmt.Errorf("load %s failed: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// Parse 解析配置内容，再使用环境变量覆盖并校验
+func Parse(b []byte, format string) (*Config, error) {
+	cfg := new(Config)
+
+	switch format {
+	case FormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parse yaml failed: %w", err)
+		}
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("parse json failed: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+
+	cfg.ApplyEnv()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ApplyEnv 使用已设置的环境变量覆盖配置，服务相关的环境变量会在服务未配置时新增该服务
+func (c *Config) ApplyEnv() {
+	setEnv(&c.AccessKey, volcano.EnvAccessKey)
+	setEnv(&c.SecretKey, volcano.EnvSecretKey)
+	setEnv(&c.SessionToken, volcano.EnvSessionToken)
+
+	if hasEnv(EnvSamiAppKey) && c.SAMI == nil {
+		c.SAMI = new(vc.Config)
+	}
+	if c.SAMI != nil {
+		setEnv(&c.SAMI.AppKey, EnvSamiAppKey)
+	}
+
+	if hasEnv(EnvTTSAccessToken, EnvTTSAppID) && c.TTS == nil {
+		c.TTS = new(tts.Config)
+	}
+	if c.TTS != nil {
+		setEnv(&c.TTS.AccessToken, EnvTTSAccessToken)
+		setEnv(&c.TTS.AppID, EnvTTSAppID)
+	}
+
+	if hasEnv(EnvMegaAccessToken, EnvMegaAppID) && c.Mega == nil {
+		c.Mega = new(mega.Config)
+	}
+	if c.Mega != nil {
+		setEnv(&c.Mega.AccessToken, EnvMegaAccessToken)
+		setEnv(&c.Mega.AppID, EnvMegaAppID)
+	}
+}
+
+// Validate 校验各服务的必填项，返回所有缺失的配置
+func (c *Config) Validate() error {
+	var errs []error
+
+	errs = append(errs, validateKeys("", c.Config))
+
+	if c.SAMI != nil {
+		errs = append(errs, validateKeys("sami", c.SAMI.Config))
+		if c.SAMI.AppKey == "" {
+			errs = append(errs, errors.New("sami: app_key is required"))
+		}
+	}
+
+	if c.TTS != nil {
+		if c.TTS.AccessToken == "" {
+			errs = append(errs, errors.New("tts: access_token is required"))
+		}
+		if c.TTS.AppID == "" {
+			errs = append(errs, errors.New("tts: app_id is required"))
+		}
+	}
+
+	if c.Mega != nil {
+		errs = append(errs, validateKeys("mega", c.Mega.Config))
+		if c.Mega.AccessToken == "" {
+			errs = append(errs, errors.New("mega: access_token is required"))
+		}
+		if c.Mega.AppID == "" {
+			errs = append(errs, errors.New("mega: app_id is required"))
+		}
+	}
+
+	if c.STS != nil {
+		errs = append(errs, validateKeys("sts", *c.STS))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// Clients 生成已配置服务的客户端，
+// 顶层配置中的Provider、HTTPClient、Logger等运行时配置会传递给所有服务
+func (c *Config) Clients() (*Clients, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	base := c.Config
+	if c.RateLimit != nil && base.Limiter == nil {
+		base.Limiter = volcano.NewLimiter(c.RateLimit.Default, c.RateLimit.Rates, c.RateLimit.MaxStreams)
+	}
+
+	var clients Clients
+
+	if c.SAMI != nil {
+		cfg := *c.SAMI
+		cfg.Config = inherit(cfg.Config, base)
+		clients.VoiceConversion = vc.New(cfg)
+	}
+
+	if c.TTS != nil {
+		cfg := *c.TTS
+		if cfg.Dialer == nil {
+			cfg.Dialer = base.Dialer
+		}
+		if cfg.Limiter == nil {
+			cfg.Limiter = base.Limiter
+		}
+		if cfg.Instrumenter == nil {
+			cfg.Instrumenter = base.Instrumenter
+		}
+		if cfg.Logger == nil {
+			cfg.Logger = base.Logger
+		}
+		clients.TTS = tts.New(cfg)
+	}
+
+	if c.Mega != nil {
+		cfg := *c.Mega
+		cfg.Config = inherit(cfg.Config, base)
+		clients.Mega = mega.New(cfg)
+	}
+
+	if c.STS != nil {
+		clients.STS = sts.New(inherit(*c.STS, base))
+	}
+
+	return &clients, nil
+}
+
+// Close 关闭客户端持有的后台资源，如SAMI的token刷新
+func (c *Clients) Close() {
+	if c.VoiceConversion != nil {
+		c.VoiceConversion.Close()
+	}
+}
+
+// New 读取配置文件并生成客户端
+func New(filename string) (*Clients, error) {
+	cfg, err := Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Clients()
+}
+
+// inherit 服务未配置的项使用顶层配置
+func inherit(cfg, base volcano.Config) volcano.Config {
+	if cfg.AccessKey == "" && cfg.SecretKey == "" {
+		cfg.AccessKey, cfg.SecretKey, cfg.SessionToken = base.AccessKey, base.SecretKey, base.SessionToken
+	}
+	cfg.Endpoint = cfg.Endpoint.Resolve(base.Endpoint)
+
+	if cfg.Provider == nil {
+		cfg.Provider = base.Provider
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = base.HTTPClient
+	}
+	if cfg.Dialer == nil {
+		cfg.Dialer = base.Dialer
+	}
+	if cfg.Retry == nil {
+		cfg.Retry = base.Retry
+	}
+	if cfg.Limiter == nil {
+		cfg.Limiter = base.Limiter
+	}
+	if cfg.Instrumenter == nil {
+		cfg.Instrumenter = base.Instrumenter
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = base.Logger
+	}
+	if cfg.SignatureHook == nil {
+		cfg.SignatureHook = base.SignatureHook
+	}
+	return cfg
+}
+
+func validateKeys(section string, cfg volcano.Config) error {
+	if section != "" {
+		section += ": "
+	}
+	if (cfg.AccessKey == "") != (cfg.SecretKey == "") {
+		return fmt.Errorf("%saccess_key and secret_key must be set together", section)
+	}
+	if cfg.SessionToken != "" && cfg.AccessKey == "" {
+		return fmt.Errorf("%ssession_token requires access_key and secret_key", section)
+	}
+	return nil
+}
+
+func setEnv(v *string, key string) {
+	if s, ok := os.LookupEnv(key); ok && s != "" {
+		*v = s
+	}
+}
+
+func hasEnv(keys ...string) bool {
+	for _, key := range keys {
+		if os.Getenv(key) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,306 @@
+package config
+
+import (
+	"context"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/sts"
+	"github.com/jyinz/volcano-sdk/volcanotest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv 清除会覆盖配置的环境变量，避免受运行环境影响
+func clearEnv(t *testing.T) {
+	for _, key := range []string{
+		volcano.EnvAccessKey, volcano.EnvSecretKey, volcano.EnvSessionToken,
+		EnvSamiAppKey, EnvTTSAccessToken, EnvTTSAppID, EnvMegaAccessToken, EnvMegaAppID,
+	} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Config)
+		wantErr []string // 错误中应包含的内容
+	}{
+		{
+			name:   "yaml",
+			format: FormatYAML,
+			content: `
+access_key: AK
+secret_key: SK
+rate_limit:
+  default: {qps: 10, burst: 10}
+  max_streams: 5
+sami:
+  app_key: APPKEY
+tts:
+  access_token: TOKEN
+  app_id: APPID
+`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.AccessKey != "AK" || cfg.SecretKey != "SK" {
+					t.Errorf("keys = %q, %q", cfg.AccessKey, cfg.SecretKey)
+				}
+				if cfg.RateLimit == nil || cfg.RateLimit.Default.QPS != 10 || cfg.RateLimit.MaxStreams != 5 {
+					t.Errorf("rate_limit = %+v", cfg.RateLimit)
+				}
+				if cfg.SAMI == nil || cfg.SAMI.AppKey != "APPKEY" || cfg.TTS == nil || cfg.TTS.AppID != "APPID" {
+					t.Errorf("services = %+v, %+v", cfg.SAMI, cfg.TTS)
+				}
+				if cfg.Mega != nil || cfg.STS != nil {
+					t.Error("unconfigured services should be nil")
+				}
+			},
+		},
+		{
+			name:    "json",
+			format:  FormatJSON,
+			content: `{"access_key": "AK", "secret_key": "SK", "session_token": "STS", "mega": {"access_token": "TOKEN", "app_id": "APPID"}}`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.SessionToken != "STS" || cfg.Mega == nil || cfg.Mega.AccessToken != "TOKEN" {
+					t.Errorf("config = %+v, mega = %+v", cfg.Config, cfg.Mega)
+				}
+			},
+		},
+		{
+			// 环境变量优先于配置文件
+			name:   "env overrides file",
+			format: FormatYAML,
+			content: `
+access_key: AK
+secret_key: SK
+tts:
+  access_token: TOKEN
+  app_id: APPID
+`,
+			env: map[string]string{
+				volcano.EnvAccessKey:    "ENV_AK",
+				volcano.EnvSecretKey:    "ENV_SK",
+				volcano.EnvSessionToken: "ENV_STS",
+				EnvTTSAccessToken:       "ENV_TOKEN",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.AccessKey != "ENV_AK" || cfg.SecretKey != "ENV_SK" || cfg.SessionToken != "ENV_STS" {
+					t.Errorf("keys = %q, %q, %q", cfg.AccessKey, cfg.SecretKey, cfg.SessionToken)
+				}
+				if cfg.TTS.AccessToken != "ENV_TOKEN" || cfg.TTS.AppID != "APPID" {
+					t.Errorf("tts = %+v", cfg.TTS)
+				}
+			},
+		},
+		{
+			name:    "env adds service",
+			format:  FormatYAML,
+			content: `access_key: AK` + "\n" + `secret_key: SK`,
+			env:     map[string]string{EnvSamiAppKey: "ENV_APPKEY"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.SAMI == nil || cfg.SAMI.AppKey != "ENV_APPKEY" {
+					t.Errorf("sami = %+v", cfg.SAMI)
+				}
+			},
+		},
+		{
+			name:   "missing fields",
+			format: FormatYAML,
+			content: `
+access_key: AK
+sami: {}
+tts: {app_id: APPID}
+mega:
+  access_key: AK
+  secret_key: SK
+  session_token: STS
+  access_token: TOKEN
+sts:
+  session_token: STS
+`,
+			wantErr: []string{
+				"access_key and secret_key must be set together",
+				"sami: app_key is required",
+				"tts: access_token is required",
+				"mega: app_id is required",
+				"sts: session_token requires access_key and secret_key",
+			},
+		},
+		{
+			name:    "unknown field",
+			format:  FormatYAML,
+			content: `access_keys: AK`,
+			wantErr: []string{"parse yaml failed"},
+		},
+		{
+			name:    "unknown format",
+			format:  "toml",
+			wantErr: []string{"unsupported config format"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Parse([]byte(tt.content), tt.format)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatal("Parse() = nil, want error")
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("Parse() = %v, want %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{name: "yaml", file: "volcano.yaml", content: "access_key: AK\nsecret_key: SK\n"},
+		{name: "yml", file: "volcano.yml", content: "access_key: AK\nsecret_key: SK\n"},
+		{name: "json", file: "volcano.json", content: `{"access_key": "AK", "secret_key": "SK"}`},
+		{name: "unsupported extension", file: "volcano.toml", content: "access_key = 'AK'", wantErr: true},
+		{name: "not exist", file: "missing.yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(dir, tt.file)
+			if tt.content != "" {
+				if err := os.WriteFile(filename, []byte(tt.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg, err := Load(filename)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() = %v", err)
+			}
+			if cfg.AccessKey != "AK" || cfg.SecretKey != "SK" {
+				t.Errorf("keys = %q, %q", cfg.AccessKey, cfg.SecretKey)
+			}
+		})
+	}
+}
+
+func TestInherit(t *testing.T) {
+	hook := func(volcano.SignatureDebug) {}
+	base := volcano.Config{
+		AccessKey:     "AK",
+		SecretKey:     "SK",
+		SessionToken:  "STS",
+		Endpoint:      volcano.Endpoint{Host: "base.example.com", Scheme: "http"},
+		Retry:         volcano.NoRetry,
+		Limiter:       volcano.NewLimiter(volcano.Rate{}, nil, 0),
+		SignatureHook: hook,
+	}
+
+	tests := []struct {
+		name      string
+		cfg       volcano.Config
+		accessKey string
+		token     string
+		host      string
+	}{
+		{name: "empty", accessKey: "AK", token: "STS", host: "base.example.com"},
+		// 服务配置了密钥时不使用顶层的session token
+		{name: "own keys", cfg: volcano.Config{AccessKey: "AK2", SecretKey: "SK2"}, accessKey: "AK2", host: "base.example.com"},
+		{name: "own endpoint", cfg: volcano.Config{Endpoint: volcano.Endpoint{Host: "own.example.com"}}, accessKey: "AK", token: "STS", host: "own.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inherit(tt.cfg, base)
+			if got.AccessKey != tt.accessKey || got.SessionToken != tt.token {
+				t.Errorf("keys = %q, %q, want %q, %q", got.AccessKey, got.SessionToken, tt.accessKey, tt.token)
+			}
+			if got.Endpoint.Host != tt.host || got.Endpoint.Scheme != "http" {
+				t.Errorf("endpoint = %+v", got.Endpoint)
+			}
+			if got.Retry == nil || got.Limiter != base.Limiter || got.SignatureHook == nil {
+				t.Errorf("runtime config not inherited: %+v", got)
+			}
+		})
+	}
+}
+
+func TestClients(t *testing.T) {
+	clearEnv(t)
+	s := volcanotest.NewServer()
+	defer s.Close()
+	s.AddCredentials("TMP_AK", "TMP_SK", "TMP_STS")
+	s.Respond("AssumeRole", "2018-01-01", sts.AssumeRoleResult{})
+
+	var signed int
+	base := s.Config()
+	base.AccessKey, base.SecretKey, base.SessionToken = "TMP_AK", "TMP_SK", "TMP_STS"
+	base.SignatureHook = func(volcano.SignatureDebug) { signed++ }
+
+	cfg := &Config{
+		Config:    base,
+		RateLimit: &RateLimit{MaxStreams: 1},
+		STS:       &volcano.Config{},
+	}
+	clients, err := cfg.Clients()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clients.Close()
+	if clients.STS == nil || clients.VoiceConversion != nil || clients.TTS != nil || clients.Mega != nil {
+		t.Fatalf("clients = %+v", clients)
+	}
+	if clients.STS.Limiter == nil {
+		t.Error("limiter from rate_limit not shared")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err = clients.STS.AssumeRole(ctx, sts.AssumeRoleRequest{RoleTrn: "trn:iam::1:role/r", RoleSessionName: "s"}); err != nil {
+		t.Fatalf("AssumeRole() = %v", err)
+	}
+
+	// sts使用顶层的临时凭证签名，并调用顶层的SignatureHook
+	reqs := s.RequestsFor("AssumeRole")
+	if len(reqs) != 1 || reqs[0].AccessKey != "TMP_AK" || reqs[0].Header.Get("X-Security-Token") != "TMP_STS" {
+		t.Fatalf("requests = %+v", reqs)
+	}
+	if signed != 1 {
+		t.Errorf("SignatureHook called %d times, want 1", signed)
+	}
+
+	cfg.STS.SecretKey = "SK"
+	if _, err = cfg.Clients(); err == nil {
+		t.Error("Clients() with invalid config = nil, want error")
+	}
+}
@@ -0,0 +1,330 @@
+package volcano
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 凭证相关的环境变量
+const (
+	EnvAccessKey       = "VOLC_ACCESSKEY"
+	EnvSecretKey       = "VOLC_SECRETKEY"
+	EnvSessionToken    = "VOLC_SESSION_TOKEN"
+	EnvProfile         = "VOLC_PROFILE"
+	EnvCredentialsFile = "VOLC_SHARED_CREDENTIALS_FILE"
+
+	_DefaultProfile = "default"
+)
+
+// ErrNoCredentials 未能获取到有效的凭证
+var ErrNoCredentials = errors.New("no valid credentials")
+
+// CredentialsValue 凭证来源返回的密钥
+type CredentialsValue struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time // 过期时间，零值表示不会过期
+	Source          string    // 凭证来源，用于排查问题
+}
+
+// Expired returns true when credentials is expired.
+func (v CredentialsValue) Expired() bool {
+	return !v.Expires.IsZero() && !now().Before(v.Expires)
+}
+
+// CredentialsProvider 凭证来源
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (CredentialsValue, error)
+}
+
+// StaticProvider 固定的密钥
+type StaticProvider struct {
+	Value CredentialsValue
+}
+
+func NewStaticProvider(accessKey, secretKey, sessionToken string) *StaticProvider {
+	return &StaticProvider{
+		Value: CredentialsValue{
+			AccessKeyID:     accessKey,
+			SecretAccessKey: secretKey,
+			SessionToken:    sessionToken,
+			Source:          "static",
+		},
+	}
+}
+
+func (p *StaticProvider) Retrieve(context.Context) (CredentialsValue, error) {
+	if p.Value.AccessKeyID == "" || p.Value.SecretAccessKey == "" {
+		return CredentialsValue{}, fmt.Errorf("%w: static access key or secret key is empty", ErrNoCredentials)
+	}
+	return p.Value, nil
+}
+
+// EnvProvider 从环境变量 VOLC_ACCESSKEY、VOLC_SECRETKEY、VOLC_SESSION_TOKEN 读取密钥
+type EnvProvider struct{}
+
+func (EnvProvider) Retrieve(context.Context) (CredentialsValue, error) {
+	v := CredentialsValue{
+		AccessKeyID:     os.Getenv(EnvAccessKey),
+		SecretAccessKey: os.Getenv(EnvSecretKey),
+		SessionToken:    os.Getenv(EnvSessionToken),
+		Source:          "env",
+	}
+	if v.AccessKeyID == "" || v.SecretAccessKey == "" {
+		return CredentialsValue{}, fmt.Errorf("%w: %s or %s not set", ErrNoCredentials, EnvAccessKey, EnvSecretKey)
+	}
+	return v, nil
+}
+
+// ProfileProvider 从共享凭证文件中读取指定profile的密钥，文件格式如下：
+//
+//	[default]
+//	access_key = AK
+//	secret_key = SK
+//	session_token = TOKEN
+type ProfileProvider struct {
+	// Filename 凭证文件路径，为空时依次使用环境变量VOLC_SHARED_CREDENTIALS_FILE、~/.volc/credentials
+	Filename string
+	// Profile 使用的profile，为空时依次使用环境变量VOLC_PROFILE、default
+	Profile string
+}
+
+func (p *ProfileProvider) Retrieve(context.Context) (CredentialsValue, error) {
+	filename, err := p.filename()
+	if err != nil {
+		return CredentialsValue{}, fmt.Errorf("%w: %w", ErrNoCredentials, err)
+	}
+
+	profile := p.Profile
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	if profile == "" {
+		profile = _DefaultProfile
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return CredentialsValue{}, fmt.Errorf("%w: %w", ErrNoCredentials, err)
+	}
+	defer f.Close()
+
+	sections, err := parseProfiles(f)
+	if err != nil {
+		return CredentialsValue{}, fmt.Errorf("parse %s failed: %w", filename, err)
+	}
+
+	section, ok := sections[profile]
+	if !ok {
+		return CredentialsValue{}, fmt.Errorf("%w: profile %s not found in %s", ErrNoCredentials, profile, filename)
+	}
+
+	v := CredentialsValue{
+		AccessKeyID:     section["access_key"],
+		SecretAccessKey: section["secret_key"],
+		SessionToken:    section["session_token"],
+		Source:          "profile:" + profile,
+	}
+	if v.AccessKeyID == "" || v.SecretAccessKey == "" {
+		return CredentialsValue{}, fmt.Errorf("%w: profile %s has no access_key or secret_key", ErrNoCredentials, profile)
+	}
+	return v, nil
+}
+
+func (p *ProfileProvider) filename() (string, error) {
+	if p.Filename != "" {
+		return p.Filename, nil
+	}
+	if f := os.Getenv(EnvCredentialsFile); f != "" {
+		return f, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".volc", "credentials"), nil
+}
+
+// parseProfiles 解析ini格式的凭证文件
+func parseProfiles(f *os.File) (map[string]map[string]string, error) {
+	var (
+		sections = make(map[string]map[string]string)
+		section  map[string]string
+		scanner  = bufio.NewScanner(f)
+		lineNo   = 0
+	)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			name = strings.TrimSpace(strings.TrimPrefix(name, "profile "))
+			section = make(map[string]string)
+			sections[name] = section
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || section == nil {
+			return nil, fmt.Errorf("line %d: unexpected %q", lineNo, line)
+		}
+		section[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return sections, scanner.Err()
+}
+
+// ChainProvider 依次尝试各个凭证来源，返回第一个成功获取的凭证
+type ChainProvider struct {
+	Providers []CredentialsProvider
+}
+
+func NewChainProvider(providers ...CredentialsProvider) *ChainProvider {
+	return &ChainProvider{Providers: providers}
+}
+
+func (p *ChainProvider) Retrieve(ctx context.Context) (CredentialsValue, error) {
+	var errs []error
+	for _, provider := range p.Providers {
+		v, err := provider.Retrieve(ctx)
+		if err == nil {
+			return v, nil
+		}
+		errs = append(errs, err)
+	}
+	return CredentialsValue{}, fmt.Errorf("%w in chain: %w", ErrNoCredentials, errors.Join(errs...))
+}
+
+const (
+	// DefaultRefreshInterval DefaultProvider重新读取环境变量和共享凭证文件的间隔，以便使用轮换后的密钥
+	DefaultRefreshInterval = 5 * time.Minute
+	// DefaultRetrieveTimeout CredentialsCache单次Provider.Retrieve的超时时间
+	DefaultRetrieveTimeout = 30 * time.Second
+)
+
+// CredentialsCache 缓存凭证来源返回的密钥，在过期前ExpiryWindow重新获取；
+// 并发的获取合并为一次Provider.Retrieve，等待方在各自的ctx结束时返回
+type CredentialsCache struct {
+	Provider     CredentialsProvider
+	ExpiryWindow time.Duration
+	// RefreshInterval 未声明过期时间的密钥缓存的时长，为0时一直缓存直至Invalidate
+	RefreshInterval time.Duration
+	// RetrieveTimeout 单次Provider.Retrieve的超时时间，为0时使用DefaultRetrieveTimeout；
+	// 超时后即使Provider未返回也结束本次获取，之后的调用重新获取
+	RetrieveTimeout time.Duration
+
+	mu        sync.Mutex
+	value     CredentialsValue
+	ok        bool
+	retrieved time.Time
+	call      *credentialsCall
+}
+
+// credentialsCall 进行中的Provider.Retrieve，done关闭后value、err为结果
+type credentialsCall struct {
+	done  chan struct{}
+	value CredentialsValue
+	err   error
+}
+
+func NewCredentialsCache(provider CredentialsProvider, expiryWindow time.Duration) *CredentialsCache {
+	return &CredentialsCache{
+		Provider:     provider,
+		ExpiryWindow: expiryWindow,
+	}
+}
+
+func (c *CredentialsCache) Retrieve(ctx context.Context) (CredentialsValue, error) {
+	c.mu.Lock()
+	if c.ok && !c.expired() {
+		v := c.value
+		c.mu.Unlock()
+		return v, nil
+	}
+
+	call := c.call
+	if call == nil {
+		call = &credentialsCall{done: make(chan struct{})}
+		c.call = call
+
+		// 获取由所有等待方共享，不受发起方ctx取消的影响
+		go c.retrieve(context.WithoutCancel(ctx), call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return CredentialsValue{}, context.Cause(ctx)
+	case <-call.done:
+		return call.value, call.err
+	}
+}
+
+func (c *CredentialsCache) retrieve(ctx context.Context, call *credentialsCall) {
+	timeout := c.RetrieveTimeout
+	if timeout <= 0 {
+		timeout = DefaultRetrieveTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		value CredentialsValue
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		v, err := c.Provider.Retrieve(ctx)
+		ch <- result{v, err}
+	}()
+
+	var r result
+	select {
+	case r = <-ch:
+	case <-ctx.Done():
+		// Provider未响应ctx时不再等待，避免后续调用一直等待这次获取
+		r.err = fmt.Errorf("retrieve credentials failed: %w", context.Cause(ctx))
+	}
+
+	c.mu.Lock()
+	if r.err == nil {
+		c.value, c.ok, c.retrieved = r.value, true, now()
+	}
+	c.call = nil
+	c.mu.Unlock()
+
+	call.value, call.err = r.value, r.err
+	close(call.done)
+}
+
+// Invalidate 清除缓存，下次获取时重新从凭证来源读取
+func (c *CredentialsCache) Invalidate() {
+	c.mu.Lock()
+	c.ok = false
+	c.mu.Unlock()
+}
+
+func (c *CredentialsCache) expired() bool {
+	if c.value.Expires.IsZero() {
+		return c.RefreshInterval > 0 && !now().Before(c.retrieved.Add(c.RefreshInterval))
+	}
+	return !now().Before(c.value.Expires.Add(-c.ExpiryWindow))
+}
+
+// DefaultProvider 默认凭证来源：依次尝试环境变量和共享凭证文件，结果缓存DefaultRefreshInterval
+func DefaultProvider() CredentialsProvider {
+	cache := NewCredentialsCache(NewChainProvider(EnvProvider{}, &ProfileProvider{}), 0)
+	cache.RefreshInterval = DefaultRefreshInterval
+	return cache
+}
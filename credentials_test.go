@@ -0,0 +1,128 @@
+package volcano
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingProvider 每次Retrieve等待release后返回递增的AccessKeyID
+type blockingProvider struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (p *blockingProvider) Retrieve(ctx context.Context) (CredentialsValue, error) {
+	n := p.calls.Add(1)
+	select {
+	case <-ctx.Done():
+		return CredentialsValue{}, ctx.Err()
+	case <-p.release:
+	}
+	return CredentialsValue{AccessKeyID: string(rune('A' + n - 1))}, nil
+}
+
+func TestCredentialsCacheSingleFlight(t *testing.T) {
+	p := &blockingProvider{release: make(chan struct{})}
+	c := NewCredentialsCache(p, 0)
+
+	// 等待中的调用方ctx结束时立即返回
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Retrieve(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Retrieve() = %v, want deadline exceeded", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.Retrieve(context.Background())
+			if err != nil || v.AccessKeyID != "A" {
+				t.Errorf("Retrieve() = %v, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(p.release)
+	wg.Wait()
+
+	if n := p.calls.Load(); n != 1 {
+		t.Errorf("provider called %d times, want 1", n)
+	}
+}
+
+func TestCredentialsCacheRefreshInterval(t *testing.T) {
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	current := base
+	defer func(old func() time.Time) { now = old }(now)
+	now = func() time.Time { return current }
+
+	t.Setenv(EnvAccessKey, "AK1")
+	t.Setenv(EnvSecretKey, "SK1")
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     string
+	}{
+		{name: "cached forever", want: "AK1"},
+		{name: "refreshed", interval: time.Minute, want: "AK2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current = base
+			t.Setenv(EnvAccessKey, "AK1")
+
+			c := NewCredentialsCache(EnvProvider{}, 0)
+			c.RefreshInterval = tt.interval
+			if v, err := c.Retrieve(context.Background()); err != nil || v.AccessKeyID != "AK1" {
+				t.Fatalf("Retrieve() = %v, %v", v, err)
+			}
+
+			t.Setenv(EnvAccessKey, "AK2")
+			current = base.Add(2 * time.Minute)
+			if v, err := c.Retrieve(context.Background()); err != nil || v.AccessKeyID != tt.want {
+				t.Fatalf("Retrieve() = %v, %v, want %s", v.AccessKeyID, err, tt.want)
+			}
+		})
+	}
+}
+
+// stuckProvider 第一次Retrieve忽略ctx一直阻塞，之后立即返回
+type stuckProvider struct {
+	calls atomic.Int32
+	stuck chan struct{}
+}
+
+func (p *stuckProvider) Retrieve(context.Context) (CredentialsValue, error) {
+	if p.calls.Add(1) == 1 {
+		<-p.stuck
+	}
+	return CredentialsValue{AccessKeyID: "AK"}, nil
+}
+
+func TestCredentialsCacheRetrieveTimeout(t *testing.T) {
+	p := &stuckProvider{stuck: make(chan struct{})}
+	defer close(p.stuck)
+
+	c := NewCredentialsCache(p, 0)
+	c.RetrieveTimeout = 20 * time.Millisecond
+
+	// 调用方自身没有超时，由RetrieveTimeout结束阻塞的获取
+	if _, err := c.Retrieve(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Retrieve() = %v, want deadline exceeded", err)
+	}
+
+	v, err := c.Retrieve(context.Background())
+	if err != nil || v.AccessKeyID != "AK" {
+		t.Fatalf("Retrieve() after timeout = %v, %v", v, err)
+	}
+	if n := p.calls.Load(); n != 2 {
+		t.Errorf("provider called %d times, want 2", n)
+	}
+}
@@ -0,0 +1,43 @@
+package volcano
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Endpoint 服务地址，未配置的字段使用各服务的默认值
+type Endpoint struct {
+	Host     string `json:"host" yaml:"host"`           // 域名，可带端口
+	Scheme   string `json:"scheme" yaml:"scheme"`       // 协议，如https、wss，本地测试时可使用http、ws
+	BasePath string `json:"base_path" yaml:"base_path"` // 路径前缀，用于私有网关等场景
+	Region   string `json:"region" yaml:"region"`       // 地域，仅用于OpenApi签名
+}
+
+// Resolve 使用def填充未配置的字段
+func (e Endpoint) Resolve(def Endpoint) Endpoint {
+	if e.Host == "" {
+		e.Host = def.Host
+	}
+	if e.Scheme == "" {
+		e.Scheme = def.Scheme
+	}
+	if e.BasePath == "" {
+		e.BasePath = def.BasePath
+	}
+	if e.Region == "" {
+		e.Region = def.Region
+	}
+	return e
+}
+
+// URL 生成指定path的url，path拼接在BasePath之后
+func (e Endpoint) URL(path string) url.URL {
+	if e.BasePath != "" {
+		path = strings.TrimSuffix(e.BasePath, "/") + "/" + strings.TrimPrefix(path, "/")
+	}
+	return url.URL{
+		Scheme: e.Scheme,
+		Host:   e.Host,
+		Path:   path,
+	}
+}
@@ -0,0 +1,145 @@
+package volcano
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type (
+	// ResponseMetadata OpenApi返回的公共信息
+	ResponseMetadata struct {
+		RequestId string         `json:"RequestId"`
+		Action    string         `json:"Action"`
+		Version   string         `json:"Version"`
+		Service   string         `json:"Service"`
+		Region    string         `json:"Region"`
+		Error     *ResponseError `json:"Error,omitempty"`
+	}
+
+	// ResponseError OpenApi返回的错误信息
+	ResponseError struct {
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+	}
+)
+
+// Category 错误分类，可配合errors.Is使用，如 errors.Is(err, volcano.ErrThrottled)
+type Category string
+
+func (c Category) Error() string {
+	return string(c)
+}
+
+const (
+	ErrThrottled        Category = "throttled"         // 限流、并发超限
+	ErrAuthFailure      Category = "auth failure"      // 鉴权失败
+	ErrInvalidParameter Category = "invalid parameter" // 参数错误
+	ErrNotFound         Category = "not found"         // 资源不存在
+)
+
+// Error 接口调用返回的错误，所有服务的接口错误均使用该类型
+type Error struct {
+	StatusCode int    // http状态码，websocket消息中返回的错误为0
+	Service    string // 服务，如sami、openspeech
+	Action     string // OpenApi的Action，或OpenSpeech、SAMI的接口路径、namespace
+	Code       string // 错误码
+	Message    string
+	RequestId  string // 请求标识，用于向服务方排查问题
+
+	// Category 错误分类，为空时根据StatusCode和Code推断
+	Category Category
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("response error(%d): service = %s, action = %s, code = %s, desc = %s, request_id = %s",
+		e.StatusCode, e.Service, e.Action, e.Code, e.Message, e.RequestId)
+}
+
+// Is 支持使用errors.Is判断错误分类
+func (e *Error) Is(target error) bool {
+	c, ok := target.(Category)
+	return ok && c != "" && e.Classify() == c
+}
+
+// Classify 返回错误分类，无法分类时返回空
+func (e *Error) Classify() Category {
+	if e.Category != "" {
+		return e.Category
+	}
+
+	if c, ok := codeCategories[e.Code]; ok {
+		return c
+	}
+	switch {
+	case strings.HasPrefix(e.Code, "Throttling") || strings.HasSuffix(e.Code, "LimitExceeded"):
+		return ErrThrottled
+	case strings.HasPrefix(e.Code, "AccessDenied"):
+		return ErrAuthFailure
+	case strings.HasPrefix(e.Code, "InvalidParameter") || strings.HasPrefix(e.Code, "MissingParameter"):
+		return ErrInvalidParameter
+	case strings.Contains(e.Code, "NotFound"):
+		return ErrNotFound
+	}
+
+	switch e.StatusCode {
+	case http.StatusTooManyRequests:
+		return ErrThrottled
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuthFailure
+	case http.StatusBadRequest:
+		return ErrInvalidParameter
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	return ""
+}
+
+// codeCategories OpenApi公共错误码分类
+var codeCategories = map[string]Category{
+	"InvalidAccessKey":       ErrAuthFailure,
+	"InvalidAuthorization":   ErrAuthFailure,
+	"InvalidCredential":      ErrAuthFailure,
+	"InvalidSecretToken":     ErrAuthFailure,
+	"InvalidTimestamp":       ErrAuthFailure,
+	"MissingSignature":       ErrAuthFailure,
+	"SignatureDoesNotMatch":  ErrAuthFailure,
+	"InvalidActionOrVersion": ErrInvalidParameter,
+	"MissingRequestInfo":     ErrInvalidParameter,
+	"ServiceNotFound":        ErrNotFound,
+}
+
+// retryableCodes 可重试的OpenApi错误码
+var retryableCodes = map[string]bool{
+	"ServiceUnavailable":     true,
+	"ServiceUnavailableTemp": true,
+	"InternalError":          true,
+	"InternalServiceError":   true,
+	"InternalServiceTimeout": true,
+}
+
+// Retryable 限流、服务端错误可重试
+func (e *Error) Retryable() bool {
+	return e.Classify() == ErrThrottled || e.StatusCode >= http.StatusInternalServerError || retryableCodes[e.Code]
+}
+
+// IsThrottled 是否为限流错误
+func IsThrottled(err error) bool {
+	return errors.Is(err, ErrThrottled)
+}
+
+// IsAuthFailure 是否为鉴权失败
+func IsAuthFailure(err error) bool {
+	return errors.Is(err, ErrAuthFailure)
+}
+
+// IsInvalidParameter 是否为参数错误
+func IsInvalidParameter(err error) bool {
+	return errors.Is(err, ErrInvalidParameter)
+}
+
+// IsNotFound 是否为资源不存在
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
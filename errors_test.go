@@ -0,0 +1,89 @@
+package volcano
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		code       string
+		category   Category
+		want       Category
+	}{
+		{name: "invalid access key", code: "InvalidAccessKey", want: ErrAuthFailure},
+		{name: "invalid authorization", code: "InvalidAuthorization", want: ErrAuthFailure},
+		{name: "invalid credential", code: "InvalidCredential", want: ErrAuthFailure},
+		{name: "invalid secret token", code: "InvalidSecretToken", want: ErrAuthFailure},
+		{name: "invalid timestamp", code: "InvalidTimestamp", want: ErrAuthFailure},
+		{name: "missing signature", code: "MissingSignature", want: ErrAuthFailure},
+		{name: "signature mismatch", statusCode: http.StatusForbidden, code: "SignatureDoesNotMatch", want: ErrAuthFailure},
+		{name: "invalid action", code: "InvalidActionOrVersion", want: ErrInvalidParameter},
+		{name: "missing request info", code: "MissingRequestInfo", want: ErrInvalidParameter},
+		{name: "service not found", code: "ServiceNotFound", want: ErrNotFound},
+		{name: "throttling prefix", code: "Throttling.User", want: ErrThrottled},
+		{name: "limit exceeded suffix", code: "FlowLimitExceeded", want: ErrThrottled},
+		{name: "access denied prefix", code: "AccessDenied.Role", want: ErrAuthFailure},
+		{name: "invalid parameter prefix", code: "InvalidParameter.RoleTrn", want: ErrInvalidParameter},
+		{name: "missing parameter prefix", code: "MissingParameter.RoleTrn", want: ErrInvalidParameter},
+		{name: "not found infix", code: "RoleNotFound.Trn", want: ErrNotFound},
+		{name: "code before status", statusCode: http.StatusTooManyRequests, code: "InvalidParameter", want: ErrInvalidParameter},
+		{name: "429", statusCode: http.StatusTooManyRequests, want: ErrThrottled},
+		{name: "401", statusCode: http.StatusUnauthorized, want: ErrAuthFailure},
+		{name: "403", statusCode: http.StatusForbidden, want: ErrAuthFailure},
+		{name: "400", statusCode: http.StatusBadRequest, want: ErrInvalidParameter},
+		{name: "404", statusCode: http.StatusNotFound, want: ErrNotFound},
+		{name: "500", statusCode: http.StatusInternalServerError},
+		{name: "unknown code", statusCode: http.StatusOK, code: "InternalError"},
+		{name: "explicit category", statusCode: http.StatusBadRequest, code: "3003", category: ErrThrottled, want: ErrThrottled},
+	}
+
+	categories := []Category{ErrThrottled, ErrAuthFailure, ErrInvalidParameter, ErrNotFound}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Error{StatusCode: tt.statusCode, Code: tt.code, Category: tt.category}
+			if got := e.Classify(); got != tt.want {
+				t.Fatalf("Classify() = %q, want %q", got, tt.want)
+			}
+
+			// 包装后仍可通过errors.Is判断，且只匹配自己的分类
+			err := fmt.Errorf("call failed: %w", e)
+			for _, c := range categories {
+				if got := errors.Is(err, c); got != (c == tt.want) {
+					t.Errorf("errors.Is(err, %q) = %v", c, got)
+				}
+			}
+			if errors.Is(err, Category("")) {
+				t.Error("errors.Is(err, \"\") = true")
+			}
+		})
+	}
+}
+
+func TestErrorHelpers(t *testing.T) {
+	tests := []struct {
+		name string
+		is   func(error) bool
+		err  error
+		want bool
+	}{
+		{name: "throttled", is: IsThrottled, err: &Error{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "auth failure", is: IsAuthFailure, err: &Error{Code: "SignatureDoesNotMatch"}, want: true},
+		{name: "invalid parameter", is: IsInvalidParameter, err: &Error{Code: "InvalidParameter"}, want: true},
+		{name: "not found", is: IsNotFound, err: &Error{StatusCode: http.StatusNotFound}, want: true},
+		{name: "other category", is: IsThrottled, err: &Error{Code: "InvalidParameter"}},
+		{name: "plain error", is: IsNotFound, err: errors.New("not found")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.is(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
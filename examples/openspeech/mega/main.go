@@ -18,20 +18,11 @@ func main() {
 	}
 
 	cli := mega.New(cfg)
-	ls, err := cli.ListSpeakers(context.TODO(), &mega.BatchListMegaTTSTrainStatusRequest{
-		LiseMegaTTSTrainStatusRequest: mega.LiseMegaTTSTrainStatusRequest{
-			SpeakerIDs: nil,
-		},
-		PageNumber: 0,
-		PageSize:   0,
-		NextToken:  "",
-		MaxResults: 0,
-	})
-	if err != nil {
-		panic(err)
-	}
+	for status, err := range cli.Speakers(context.TODO(), mega.BatchListMegaTTSTrainStatusRequest{}) {
+		if err != nil {
+			panic(err)
+		}
 
-	for _, status := range ls.Statuses {
 		fmt.Println("speaker:", fmt.Sprintf("%+v", status))
 	}
 }
@@ -32,6 +32,7 @@ func main() {
 	}()
 
 	cli := vc.New(cfg)
+	defer cli.Close()
 	size := 0
 	err := cli.Conversion(context.TODO(), vc.VoiceConversionRequest{
 		Speaker: "zh_female_tianmei_stream",
@@ -1,10 +1,11 @@
 module github.com/jyinz/volcano-sdk
 
-go 1.22.1
+go 1.23
 
 require (
 	github.com/gorilla/websocket v1.5.1
 	github.com/satori/go.uuid v1.2.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
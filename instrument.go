@@ -0,0 +1,47 @@
+package volcano
+
+import "context"
+
+// 被观测调用的类型
+const (
+	KindOpenApi   = "openapi"   // OpenApi接口
+	KindHTTP      = "http"      // OpenSpeech等http接口
+	KindWebSocket = "websocket" // websocket会话，如语音合成、音色转换
+)
+
+// Operation 被观测的调用
+type Operation struct {
+	Kind    string // 调用类型
+	Service string // 服务，如sami、openspeech
+	Action  string // OpenApi的Action，或接口路径、namespace
+}
+
+// Instrumenter 观测接口调用，用于接入trace和metrics
+type Instrumenter interface {
+	// Start 开始一次调用，返回的ctx用于传递trace信息
+	Start(ctx context.Context, op Operation) (context.Context, Span)
+}
+
+// Span 一次调用的观测数据，websocket会话中收发在不同goroutine进行，实现需保证并发安全
+type Span interface {
+	// FirstChunk 收到第一个数据包时调用，用于统计首包时延；仅第一次调用有效
+	FirstChunk()
+	// AddBytes 累加发送和接收的字节数
+	AddBytes(out, in int)
+	// End 调用结束，status为http状态码或服务返回码，err为调用返回的错误
+	End(status int, err error)
+}
+
+// StartSpan 使用instrumenter开始一次调用，instrumenter为nil时返回不做任何记录的Span
+func StartSpan(ctx context.Context, instrumenter Instrumenter, op Operation) (context.Context, Span) {
+	if instrumenter == nil {
+		return ctx, nopSpan{}
+	}
+	return instrumenter.Start(ctx, op)
+}
+
+type nopSpan struct{}
+
+func (nopSpan) FirstChunk()       {}
+func (nopSpan) AddBytes(int, int) {}
+func (nopSpan) End(int, error)    {}
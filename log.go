@@ -0,0 +1,174 @@
+package volcano
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted 日志中敏感信息的替换值
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys 需要脱敏的日志属性名和header，比较时忽略大小写、'_'和'-'
+var sensitiveKeys = map[string]bool{
+	"accesskey":       true,
+	"accesskeyid":     true,
+	"secretkey":       true,
+	"secretaccesskey": true,
+	"sessiontoken":    true,
+	"xsecuritytoken":  true,
+	"token":           true,
+	"accesstoken":     true,
+	"authorization":   true,
+}
+
+// IsSensitive 判断日志属性名或header是否为敏感信息
+func IsSensitive(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return sensitiveKeys[key]
+}
+
+// Redact 返回脱敏后的值，空值保持为空便于排查是否配置
+func Redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// RedactHeader 返回脱敏后的header副本
+func RedactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for k, vs := range h {
+		if IsSensitive(k) {
+			for i := range vs {
+				vs[i] = Redact(vs[i])
+			}
+		}
+	}
+	return h
+}
+
+// LogValue 日志中不输出密钥
+func (c Credentials) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("access_key", Redact(c.AccessKeyID)),
+		slog.String("secret_key", Redact(c.SecretAccessKey)),
+		slog.String("session_token", Redact(c.SessionToken)),
+		slog.String("service", c.Service),
+		slog.String("region", c.Region),
+	)
+}
+
+// LogValue 日志中不输出密钥
+func (v CredentialsValue) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("access_key", Redact(v.AccessKeyID)),
+		slog.String("secret_key", Redact(v.SecretAccessKey)),
+		slog.String("session_token", Redact(v.SessionToken)),
+		slog.Time("expires", v.Expires),
+		slog.String("source", v.Source),
+	)
+}
+
+// LogValue 日志中不输出规范请求中的临时凭证token和签名值，签名值在有效期内可被重放
+func (d SignatureDebug) LogValue() slog.Value {
+	lines := strings.Split(d.CanonicalRequest, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "x-security-token:") {
+			lines[i] = "x-security-token:" + Redacted
+		} else if strings.HasPrefix(line, "authorization:") {
+			lines[i] = "authorization:" + Redacted
+		} else if strings.Contains(line, "X-Security-Token=") || strings.Contains(line, "X-Signature=") {
+			lines[i] = sensitiveQuery.ReplaceAllString(line, "${1}"+url.QueryEscape(Redacted))
+		}
+	}
+
+	return slog.GroupValue(
+		slog.String("canonical_request", strings.Join(lines, "\n")),
+		slog.String("signed_headers", d.SignedHeaders),
+		slog.String("credential_scope", d.CredentialScope),
+		slog.String("string_to_sign", d.StringToSign),
+		slog.String("signature", Redact(d.Signature)),
+	)
+}
+
+var sensitiveQuery = regexp.MustCompile(`((?:^|&)X-(?:Security-Token|Signature)=)[^&]*`)
+
+// RedactHandler 对敏感属性和http.Header脱敏后交给下层Handler输出
+type RedactHandler struct {
+	slog.Handler
+}
+
+func NewRedactHandler(h slog.Handler) *RedactHandler {
+	return &RedactHandler{Handler: h}
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, nr)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &RedactHandler{Handler: h.Handler.WithAttrs(redacted)}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	switch {
+	case a.Value.Kind() == slog.KindGroup:
+		attrs := a.Value.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			redacted[i] = redactAttr(ga)
+		}
+		a.Value = slog.GroupValue(redacted...)
+	case IsSensitive(a.Key):
+		if a.Value.Kind() == slog.KindString {
+			a.Value = slog.StringValue(Redact(a.Value.String()))
+		} else {
+			a.Value = slog.StringValue(Redacted)
+		}
+	case a.Value.Kind() == slog.KindAny:
+		if h, ok := a.Value.Any().(http.Header); ok {
+			a.Value = slog.AnyValue(RedactHeader(h))
+		}
+	}
+	return a
+}
+
+// NewLogger 返回自动脱敏的logger，l为nil时返回不输出任何日志的logger
+func NewLogger(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return discardLogger
+	}
+	if _, ok := l.Handler().(*RedactHandler); ok {
+		return l
+	}
+	return slog.New(NewRedactHandler(l.Handler()))
+}
+
+var discardLogger = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
@@ -0,0 +1,106 @@
+package volcano
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return NewLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
+// signatureOf 返回Authorization header中的签名值
+func signatureOf(r *http.Request) string {
+	_, sig, _ := strings.Cut(r.Header.Get("Authorization"), "Signature=")
+	return sig
+}
+
+func checkRedacted(t *testing.T, out string, secrets ...string) {
+	t.Helper()
+
+	for _, s := range secrets {
+		if s != "" && strings.Contains(out, s) {
+			t.Errorf("log contains %q:\n%s", s, out)
+		}
+	}
+	if !strings.Contains(out, Redacted) {
+		t.Errorf("log contains no %s:\n%s", Redacted, out)
+	}
+}
+
+func TestLogRedaction(t *testing.T) {
+	c := Credentials{AccessKeyID: "AKLTlogtest", SecretAccessKey: "log-secret", SessionToken: "log-session", Service: "iam", Region: "cn-north-1"}
+
+	header := c.Sign(newTestRequest(http.MethodPost, "Action=ListUsers&Version=2018-01-01", `{}`))
+	_, debug := c.SignDebug(newTestRequest(http.MethodPost, "Action=ListUsers&Version=2018-01-01", `{}`))
+
+	presigned := newTestRequest(http.MethodGet, "Action=ListUsers&Version=2018-01-01", "")
+	query, err := c.PresignUrl(presigned, time.Minute, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	presigned.URL.RawQuery = query
+	_, queryDebug := c.signDebug(presigned, nil, "", now())
+
+	tests := []struct {
+		name    string
+		args    []any
+		secrets []string
+	}{
+		{name: "credentials", args: []any{"credentials", c}, secrets: []string{c.AccessKeyID, c.SecretAccessKey, c.SessionToken}},
+		{
+			name:    "credentials value",
+			args:    []any{"value", CredentialsValue{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken}},
+			secrets: []string{c.AccessKeyID, c.SecretAccessKey, c.SessionToken},
+		},
+		{name: "signature debug", args: []any{"signature", debug}, secrets: []string{c.SecretAccessKey, c.SessionToken, debug.Signature}},
+		{name: "signature debug with token in query", args: []any{"signature", queryDebug}, secrets: []string{c.SessionToken, queryDebug.Signature, presigned.URL.Query().Get("X-Signature")}},
+		{name: "signed header", args: []any{"header", header.Header}, secrets: []string{c.SessionToken, signatureOf(header)}},
+		{name: "sensitive keys", args: []any{"secret_key", c.SecretAccessKey, "X-Security-Token", c.SessionToken, "access_token", "tts-token"}, secrets: []string{c.SecretAccessKey, c.SessionToken, "tts-token"}},
+		{name: "nested group", args: []any{slog.Group("auth", "token", "sami-token", "authorization", header.Header.Get("Authorization"))}, secrets: []string{"sami-token", signatureOf(header)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			newTestLogger(&buf).Debug("test", tt.args...)
+			checkRedacted(t, buf.String(), tt.secrets...)
+		})
+	}
+
+	t.Run("with attrs", func(t *testing.T) {
+		var buf bytes.Buffer
+		newTestLogger(&buf).With("secret_key", c.SecretAccessKey).Debug("test")
+		checkRedacted(t, buf.String(), c.SecretAccessKey)
+	})
+}
+
+func TestOpenApiLogRedaction(t *testing.T) {
+	var signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = signatureOf(r)
+		_, _ = w.Write([]byte(`{"ResponseMetadata":{"RequestId":"req"},"Result":{}}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	c := NewOpenApi(Config{
+		Provider: NewStaticProvider("AKLTlogtest", "log-secret", "log-session"),
+		Endpoint: Endpoint{Host: strings.TrimPrefix(srv.URL, "http://"), Scheme: "http"},
+		Logger:   slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}, "iam", "cn-north-1")
+	if err := c.Invoke(context.Background(), "CreateUser", "2018-01-01", map[string]string{"UserName": "alice"}, nil); err != nil {
+		t.Fatalf("Invoke() = %v", err)
+	}
+
+	if signature == "" {
+		t.Fatal("request not signed")
+	}
+	checkRedacted(t, buf.String(), "log-secret", "log-session", signature)
+}
@@ -1,29 +1,315 @@
 package volcano
 
-import "net/url"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
 
 const (
 	_Host   = "open.volcengineapi.com"
 	_Scheme = "https"
+	_Path   = "/"
 )
 
 type Config struct {
 	AccessKey string `json:"access_key" yaml:"access_key"`
 	SecretKey string `json:"secret_key" yaml:"secret_key"`
+	// SessionToken 临时凭证的token，与AccessKey、SecretKey一起使用
+	SessionToken string `json:"session_token,omitempty" yaml:"session_token,omitempty"`
+
+	// Endpoint OpenApi地址，默认为 https://open.volcengineapi.com 和各服务的默认地域
+	Endpoint Endpoint `json:"endpoint" yaml:"endpoint"`
+
+	// Provider 凭证来源，优先于AccessKey、SecretKey；两者均为空时使用DefaultProvider
+	Provider CredentialsProvider `json:"-" yaml:"-"`
+
+	// HTTPClient 发送http请求使用的client，为空时使用http.DefaultClient
+	HTTPClient *http.Client `json:"-" yaml:"-"`
+	// Dialer 建立websocket连接使用的dialer，为空时使用websocket.DefaultDialer
+	Dialer *websocket.Dialer `json:"-" yaml:"-"`
+
+	// Retry 重试策略，为空时使用DefaultRetryPolicy，不需要重试时使用NoRetry
+	Retry RetryPolicy `json:"-" yaml:"-"`
+
+	// Limiter 客户端限流器，可在多个客户端间共享，为空时不限流
+	Limiter *Limiter `json:"-" yaml:"-"`
+
+	// Instrumenter 记录调用的trace和metrics，为空时不记录
+	Instrumenter Instrumenter `json:"-" yaml:"-"`
+
+	// Logger 以Debug级别记录请求和返回信息，密钥和token会被脱敏，为空时不输出日志
+	Logger *slog.Logger `json:"-" yaml:"-"`
+
+	// SignatureHook 每次签名后调用，可用于记录规范请求、待签名字符串以排查签名错误
+	SignatureHook func(SignatureDebug) `json:"-" yaml:"-"`
 }
 
 type OpenApi struct {
 	Credentials
+
+	// Endpoint OpenApi地址，未配置的字段使用默认值
+	Endpoint Endpoint
+
+	// Provider 凭证来源，不为空时每次签名前获取当前有效的密钥
+	Provider CredentialsProvider
+
+	// HTTPClient 为空时使用http.DefaultClient
+	HTTPClient *http.Client
+
+	// Retry Invoke使用的重试策略，为空时不重试
+	Retry RetryPolicy
+
+	// Limiter 按Service/Action限流，为空时不限流
+	Limiter *Limiter
+
+	// Instrumenter 为空时不记录
+	Instrumenter Instrumenter
+
+	// Logger 为空时不输出日志
+	Logger *slog.Logger
+
+	// SignatureHook 为空时不调用
+	SignatureHook func(SignatureDebug)
+
+	// clock 根据服务端时间修正签名时间
+	clock *clock
+}
+
+// NewOpenApi 根据配置生成指定服务和地域的OpenApi
+func NewOpenApi(cfg Config, service, region string) OpenApi {
+	provider := cfg.Provider
+	if provider == nil && cfg.AccessKey == "" && cfg.SecretKey == "" {
+		provider = DefaultProvider()
+	}
+
+	endpoint := cfg.Endpoint.Resolve(Endpoint{Region: region})
+
+	retry := cfg.Retry
+	if retry == nil {
+		retry = DefaultRetryPolicy
+	}
+
+	return OpenApi{
+		Credentials: Credentials{
+			AccessKeyID:     cfg.AccessKey,
+			SecretAccessKey: cfg.SecretKey,
+			SessionToken:    cfg.SessionToken,
+			Service:         service,
+			Region:          endpoint.Region,
+		},
+		Endpoint:      endpoint,
+		Provider:      provider,
+		HTTPClient:    cfg.HTTPClient,
+		Retry:         retry,
+		Limiter:       cfg.Limiter,
+		Instrumenter:  cfg.Instrumenter,
+		Logger:        cfg.Logger,
+		SignatureHook: cfg.SignatureHook,
+		clock:         new(clock),
+	}
 }
 
 func (c *OpenApi) BuildUrl(path, version, action string) url.URL {
-	return url.URL{
-		Scheme: _Scheme,
-		Host:   _Host,
-		Path:   path,
-		RawQuery: url.Values{
-			"Version": []string{version},
-			"Action":  []string{action},
-		}.Encode(),
+	u := c.Endpoint.Resolve(Endpoint{Host: _Host, Scheme: _Scheme}).URL(path)
+	u.RawQuery = url.Values{
+		"Version": []string{version},
+		"Action":  []string{action},
+	}.Encode()
+	return u
+}
+
+// CurrentCredentials 返回当前有效的凭证
+func (c *OpenApi) CurrentCredentials(ctx context.Context) (Credentials, error) {
+	if c.Provider == nil {
+		return c.Credentials, nil
+	}
+
+	v, err := c.Provider.Retrieve(ctx)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("retrieve credentials failed: %w", err)
+	}
+
+	credentials := c.Credentials
+	credentials.AccessKeyID, credentials.SecretAccessKey, credentials.SessionToken = v.AccessKeyID, v.SecretAccessKey, v.SessionToken
+	return credentials, nil
+}
+
+// ClockOffset 服务端时间与本地时间的偏差，签名时间会按照该偏差修正；未检测到偏差时为0
+func (c *OpenApi) ClockOffset() time.Duration {
+	return c.clock.Offset()
+}
+
+// SignRequest 使用当前有效的凭证对请求签名，签名时间按照ClockOffset修正
+func (c *OpenApi) SignRequest(ctx context.Context, request *http.Request) (*http.Request, error) {
+	credentials, err := c.CurrentCredentials(ctx)
+	if err != nil {
+		return nil, err
 	}
+
+	request, debug := credentials.signDebug(request, readAndReplaceBody(request), "", c.clock.now())
+	if c.SignatureHook != nil {
+		c.SignatureHook(debug)
+	}
+	NewLogger(c.Logger).DebugContext(ctx, "volcano openapi signature", "service", c.Service, "signature", debug)
+	return request, nil
+}
+
+// Invoke 调用OpenApi接口并将结果解析到result中，result需为指针或nil。
+// body为url.Values时以GET query的方式请求，否则序列化为json后POST；
+// 返回体为标准的ResponseMetadata/Result结构时解析Result，否则解析整个返回体；
+// 接口返回错误时返回*Error；调用失败时按照OpenApi.Retry重试；
+// 因本地时间偏差导致签名时间错误时，按照服务端时间修正后立即重试一次
+func (c *OpenApi) Invoke(ctx context.Context, action, version string, body, result any) error {
+	return Retry(ctx, c.Retry, func(ctx context.Context) error {
+		offset := c.ClockOffset()
+		err := c.invoke(ctx, action, version, body, result)
+		if IsClockSkewed(err) && c.ClockOffset() != offset {
+			err = c.invoke(ctx, action, version, body, result)
+		}
+		return err
+	})
+}
+
+func (c *OpenApi) invoke(ctx context.Context, action, version string, body, result any) (err error) {
+	err = c.Limiter.Wait(ctx, c.Service, action)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := StartSpan(ctx, c.Instrumenter, Operation{Kind: KindOpenApi, Service: c.Service, Action: action})
+	var status int
+	defer func() { span.End(status, err) }()
+
+	// 每次调用重新签名，保证重试时X-Date为当前时间
+	req, err := c.newRequest(ctx, action, version, body)
+	if err != nil {
+		return err
+	}
+	span.AddBytes(int(req.ContentLength), 0)
+
+	req, err = c.SignRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	log := NewLogger(c.Logger).With("service", c.Service, "action", action)
+	log.DebugContext(ctx, "volcano openapi request", "method", req.Method, "url", req.URL.String(), "header", req.Header)
+
+	var (
+		start     = time.Now()
+		requestId string
+	)
+	defer func() {
+		log.DebugContext(ctx, "volcano openapi response", "status", status, "request_id", requestId, "elapsed", time.Since(start), "error", err)
+	}()
+
+	sent := now()
+	rsp, err := c.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("do request failed: %w", err)
+	}
+	status = rsp.StatusCode
+	span.FirstChunk()
+
+	if offset, changed := c.clock.observe(rsp.Header.Get("Date"), sent, now()); changed {
+		log.WarnContext(ctx, "volcano clock skew detected", "offset", offset)
+	}
+
+	defer rsp.Body.Close()
+
+	rb, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	span.AddBytes(0, len(rb))
+
+	var ret struct {
+		ResponseMetadata ResponseMetadata `json:"ResponseMetadata"`
+		Result           json.RawMessage  `json:"Result,omitempty"`
+	}
+	err = json.Unmarshal(rb, &ret)
+	requestId = ret.ResponseMetadata.RequestId
+	if err != nil && rsp.StatusCode/100 == 2 {
+		return fmt.Errorf("parse data failed: %w", err)
+	}
+
+	// 请求失败，解析错误原因
+	if _err := ret.ResponseMetadata.Error; _err != nil {
+		return &Error{
+			StatusCode: rsp.StatusCode,
+			Service:    c.Service,
+			Action:     action,
+			Code:       _err.Code,
+			Message:    _err.Message,
+			RequestId:  ret.ResponseMetadata.RequestId,
+		}
+	}
+	if rsp.StatusCode/100 != 2 {
+		return &Error{
+			StatusCode: rsp.StatusCode,
+			Service:    c.Service,
+			Action:     action,
+			Message:    string(rb),
+			RequestId:  ret.ResponseMetadata.RequestId,
+		}
+	}
+
+	if result == nil {
+		return nil
+	}
+	if len(ret.Result) == 0 {
+		ret.Result = rb
+	}
+	err = json.Unmarshal(ret.Result, result)
+	if err != nil {
+		return fmt.Errorf("parse result failed: %w", err)
+	}
+
+	return nil
+}
+
+func (c *OpenApi) client() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *OpenApi) newRequest(ctx context.Context, action, version string, body any) (*http.Request, error) {
+	u := c.BuildUrl(_Path, version, action)
+
+	if query, ok := body.(url.Values); ok {
+		q := u.Query()
+		for k, v := range query {
+			q[k] = v
+		}
+		u.RawQuery = q.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("bad request: %w", err)
+		}
+		return req, nil
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal body failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
 }
@@ -0,0 +1,72 @@
+package volcano
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PresignUrl 生成带有效期的签名url参数，有效期以秒为精度并参与签名；date为零值时使用当前时间
+func (c Credentials) PresignUrl(request *http.Request, expires time.Duration, date time.Time) (string, error) {
+	if expires < time.Second {
+		return "", fmt.Errorf("invalid expires: %s", expires)
+	}
+	if date.IsZero() {
+		date = now()
+	}
+
+	query := request.URL.Query()
+	query.Set("X-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+
+	return c.signUrl(request, query, date.UTC()), nil
+}
+
+// PresignedUrl 预签名url中携带的签名信息
+type PresignedUrl struct {
+	AccessKeyID string
+	Region      string
+	Service     string
+	Date        time.Time     // 签名时间
+	Expires     time.Duration // 有效期，为0时表示未声明有效期（SignUrl生成）
+}
+
+// ExpiresAt 返回过期时间，未声明有效期时返回零值
+func (p PresignedUrl) ExpiresAt() time.Time {
+	if p.Expires == 0 {
+		return time.Time{}
+	}
+	return p.Date.Add(p.Expires)
+}
+
+// Expired returns true when presigned url is expired.
+func (p PresignedUrl) Expired() bool {
+	return p.Expires != 0 && now().After(p.ExpiresAt())
+}
+
+// ParsePresignedUrl 解析预签名url中的签名信息，不校验签名；
+// url已过期时同时返回解析结果和Part为SignaturePartExpires的*SignatureError
+func ParsePresignedUrl(u *url.URL) (*PresignedUrl, error) {
+	info, err := parseQueryV4(u.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse(timeFormatV4, info.xDate)
+	if err != nil {
+		return nil, signatureError(SignaturePartDate, "invalid date %q", info.xDate)
+	}
+
+	p := &PresignedUrl{
+		AccessKeyID: info.accessKeyID,
+		Region:      info.region,
+		Service:     info.service,
+		Date:        date,
+		Expires:     info.expires,
+	}
+	if p.Expired() {
+		return p, signatureError(SignaturePartExpires, "presigned url expired at %s", p.ExpiresAt().Format(time.RFC3339))
+	}
+	return p, nil
+}
@@ -0,0 +1,74 @@
+package volcano
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParsePresignedUrl(t *testing.T) {
+	date := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	defer func(old func() time.Time) { now = old }(now)
+
+	tests := []struct {
+		name   string
+		tamper func(r *http.Request)
+		now    time.Duration // 解析时间相对签名时间的偏差
+		part   SignaturePart // 为空时期望解析和验签都通过
+	}{
+		{name: "ok"},
+		{name: "before expires", now: 59 * time.Second},
+		{name: "expired", now: 61 * time.Second, part: SignaturePartExpires},
+		{name: "invalid expires", tamper: func(r *http.Request) { setQuery(r, "X-Expires", "-1") }, part: SignaturePartExpires},
+		{name: "invalid date", tamper: func(r *http.Request) { setQuery(r, "X-Date", "yesterday") }, part: SignaturePartDate},
+		{name: "malformed credential", tamper: func(r *http.Request) { setQuery(r, "X-Credential", "AKTEST") }, part: SignaturePartAuthorization},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRequest(http.MethodGet, "Action=ListUsers&Version=2018-01-01", "")
+			query, err := testCredentials.PresignUrl(r, time.Minute, date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.URL.RawQuery = query
+			if tt.tamper != nil {
+				tt.tamper(r)
+			}
+
+			now = func() time.Time { return date.Add(tt.now) }
+			p, err := ParsePresignedUrl(r.URL)
+			checkSignatureError(t, err, tt.part)
+			if tt.part != "" {
+				if tt.part == SignaturePartExpires && p != nil && !p.Expired() {
+					t.Error("Expired() = false for expired url")
+				}
+				return
+			}
+
+			want := PresignedUrl{AccessKeyID: "AKTEST", Region: "cn-north-1", Service: "iam", Date: date, Expires: time.Minute}
+			if *p != want {
+				t.Errorf("ParsePresignedUrl() = %+v, want %+v", *p, want)
+			}
+			if !p.ExpiresAt().Equal(date.Add(time.Minute)) {
+				t.Errorf("ExpiresAt() = %v", p.ExpiresAt())
+			}
+
+			// 解析出的信息可用于查找密钥并验签
+			v := Verifier{
+				Lookup: func(ak string) (Credentials, bool) {
+					return testCredentials, ak == p.AccessKeyID
+				},
+				Now: now,
+			}
+			checkSignatureError(t, v.Verify(r), "")
+		})
+	}
+}
+
+func TestPresignUrlInvalidExpires(t *testing.T) {
+	r := newTestRequest(http.MethodGet, "Action=ListUsers&Version=2018-01-01", "")
+	if _, err := testCredentials.PresignUrl(r, time.Millisecond, time.Time{}); err == nil {
+		t.Error("PresignUrl() with expires < 1s = nil, want error")
+	}
+}
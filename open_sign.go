@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -18,12 +19,16 @@ import (
 
 const (
 	timeFormatV4 = "20060102T150405Z"
+
+	// UnsignedPayload 请求体不参与签名时使用的X-Content-Sha256，需服务端支持
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
 )
 
 type (
 	RequestParam struct {
 		IsSignUrl bool
 		Body      []byte
+		BodyHash  string // 请求体的十六进制sha256，不为空时不再对Body计算哈希
 		Method    string
 		Date      time.Time
 		Path      string
@@ -32,6 +37,15 @@ type (
 		Headers   http.Header
 	}
 
+	// SignatureDebug 签名的中间结果，用于与服务端对比排查SignatureDoesNotMatch
+	SignatureDebug struct {
+		CanonicalRequest string // 规范请求
+		SignedHeaders    string // 参与签名的header，以;分隔
+		CredentialScope  string // 凭证范围 date/region/service/request
+		StringToSign     string // 待签名字符串
+		Signature        string // 签名值
+	}
+
 	SignRequest struct {
 		XDate          string
 		XNotSignBody   string
@@ -58,6 +72,68 @@ type Credentials struct {
 }
 
 func (c Credentials) Sign(request *http.Request) *http.Request {
+	return c.sign(request, readAndReplaceBody(request), "")
+}
+
+// SignWithContentHash 使用调用方提供的请求体哈希进行签名，不读取请求体
+func (c Credentials) SignWithContentHash(request *http.Request, contentHash string) *http.Request {
+	return c.sign(request, nil, contentHash)
+}
+
+// SignUnsignedPayload 请求体不参与签名，仅用于服务端允许的场景
+func (c Credentials) SignUnsignedPayload(request *http.Request) *http.Request {
+	return c.sign(request, nil, UnsignedPayload)
+}
+
+// SignReadSeeker 流式计算body的哈希后签名，并将body设置为请求体，避免将请求体整体读入内存
+func (c Credentials) SignReadSeeker(request *http.Request, body io.ReadSeeker) (*http.Request, error) {
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("seek body failed: %w", err)
+	}
+
+	h := sha256.New()
+	size, err := io.Copy(h, body)
+	if err != nil {
+		return nil, fmt.Errorf("hash body failed: %w", err)
+	}
+
+	_, err = body.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, fmt.Errorf("seek body failed: %w", err)
+	}
+
+	request.Body = io.NopCloser(body)
+	request.ContentLength = size
+	request.GetBody = func() (io.ReadCloser, error) {
+		_, err := body.Seek(start, io.SeekStart)
+		return io.NopCloser(body), err
+	}
+
+	return c.sign(request, nil, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// HashPayload 流式计算请求体的十六进制sha256，可配合SignWithContentHash使用
+func HashPayload(body io.Reader) (string, error) {
+	h := sha256.New()
+	_, err := io.Copy(h, body)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SignDebug 与Sign相同，同时返回签名的中间结果
+func (c Credentials) SignDebug(request *http.Request) (*http.Request, SignatureDebug) {
+	return c.signDebug(request, readAndReplaceBody(request), "", now())
+}
+
+func (c Credentials) sign(request *http.Request, body []byte, bodyHash string) *http.Request {
+	request, _ = c.signDebug(request, body, bodyHash, now())
+	return request
+}
+
+func (c Credentials) signDebug(request *http.Request, body []byte, bodyHash string, date time.Time) (*http.Request, SignatureDebug) {
 	query := request.URL.Query()
 	request.URL.RawQuery = query.Encode()
 
@@ -66,15 +142,16 @@ func (c Credentials) Sign(request *http.Request) *http.Request {
 	}
 	requestParam := RequestParam{
 		IsSignUrl: false,
-		Body:      readAndReplaceBody(request),
+		Body:      body,
+		BodyHash:  bodyHash,
 		Host:      request.Host,
 		Path:      request.URL.Path,
 		Method:    request.Method,
-		Date:      now(),
+		Date:      date,
 		QueryList: query,
 		Headers:   request.Header,
 	}
-	signRequest := GetSignRequest(requestParam, c)
+	signRequest, debug := GetSignRequestDebug(requestParam, c)
 
 	request.Header.Set("Host", signRequest.Host)
 	request.Header.Set("Content-Type", signRequest.ContentType)
@@ -84,19 +161,21 @@ func (c Credentials) Sign(request *http.Request) *http.Request {
 	if signRequest.XSecurityToken != "" {
 		request.Header.Set("X-Security-Token", signRequest.XSecurityToken)
 	}
-	return request
+	return request, debug
 }
 
 func (c Credentials) SignUrl(request *http.Request) string {
-	query := request.URL.Query()
+	return c.signUrl(request, request.URL.Query(), now())
+}
 
+func (c Credentials) signUrl(request *http.Request, query url.Values, date time.Time) string {
 	requestParam := RequestParam{
 		IsSignUrl: true,
 		Body:      readAndReplaceBody(request),
 		Host:      request.Host,
 		Path:      request.URL.Path,
 		Method:    request.Method,
-		Date:      now(),
+		Date:      date,
 		QueryList: query,
 		Headers:   request.Header,
 	}
@@ -116,6 +195,12 @@ func (c Credentials) SignUrl(request *http.Request) string {
 }
 
 func GetSignRequest(requestParam RequestParam, credentials Credentials) SignRequest {
+	signRequest, _ := GetSignRequestDebug(requestParam, credentials)
+	return signRequest
+}
+
+// GetSignRequestDebug 与GetSignRequest相同，同时返回规范请求、待签名字符串等中间结果
+func GetSignRequestDebug(requestParam RequestParam, credentials Credentials) (SignRequest, SignatureDebug) {
 	formatDate := appointTimestampV4(requestParam.Date)
 	meta := getMetaData(credentials, tsDateV4(formatDate))
 
@@ -156,7 +241,9 @@ func GetSignRequest(requestParam RequestParam, credentials Credentials) SignRequ
 		}
 		requestSignMap["X-Date"], requestSignMap["Host"], requestSignMap["Content-Type"] = []string{formatDate}, []string{requestParam.Host}, []string{signRequest.ContentType}
 
-		if len(requestParam.Body) == 0 {
+		if requestParam.BodyHash != "" {
+			bodyHash = requestParam.BodyHash
+		} else if len(requestParam.Body) == 0 {
 			bodyHash = hashSHA256([]byte{})
 		} else {
 			bodyHash = hashSHA256(requestParam.Body)
@@ -165,13 +252,13 @@ func GetSignRequest(requestParam RequestParam, credentials Credentials) SignRequ
 		signRequest.Host, signRequest.XContentSha256 = requestParam.Host, bodyHash
 	}
 
-	signature := getSignatureStr(requestParam, meta, credentials.SecretAccessKey, formatDate, requestSignMap, bodyHash)
+	debug := signatureDebugV4(requestParam, meta, credentials.SecretAccessKey, formatDate, requestSignMap, bodyHash)
 	if requestParam.IsSignUrl {
-		signRequest.XSignature = signature
+		signRequest.XSignature = debug.Signature
 	} else {
-		signRequest.Authorization = buildAuthHeaderV4(signature, meta, credentials)
+		signRequest.Authorization = buildAuthHeaderV4(debug.Signature, meta, credentials)
 	}
-	return signRequest
+	return signRequest, debug
 }
 
 type metadata struct {
@@ -183,20 +270,26 @@ type metadata struct {
 	service         string
 }
 
-func getSignatureStr(requestParam RequestParam, meta *metadata, secretAccessKey string,
-	formatDate string, requestSignMap map[string][]string, bodyHash string) string {
+func signatureDebugV4(requestParam RequestParam, meta *metadata, secretAccessKey string,
+	formatDate string, requestSignMap map[string][]string, bodyHash string) SignatureDebug {
 	// Task 1
-	hashedCanonReq := hashedCanonicalRequestV4(requestParam, meta, requestSignMap, bodyHash)
+	canonicalRequest := canonicalRequestV4(requestParam, meta, requestSignMap, bodyHash)
 
 	// Task 2
-	stringToSign := concat("\n", meta.algorithm, formatDate, meta.credentialScope, hashedCanonReq)
+	stringToSign := concat("\n", meta.algorithm, formatDate, meta.credentialScope, hashSHA256([]byte(canonicalRequest)))
 
 	// Task 3
 	signingKey := signingKeyV4(secretAccessKey, meta.date, meta.region, meta.service)
-	return signatureV4(signingKey, stringToSign)
+	return SignatureDebug{
+		CanonicalRequest: canonicalRequest,
+		SignedHeaders:    meta.signedHeaders,
+		CredentialScope:  meta.credentialScope,
+		StringToSign:     stringToSign,
+		Signature:        signatureV4(signingKey, stringToSign),
+	}
 }
 
-func hashedCanonicalRequestV4(param RequestParam, meta *metadata, requestSignMap map[string][]string, bodyHash string) string {
+func canonicalRequestV4(param RequestParam, meta *metadata, requestSignMap map[string][]string, bodyHash string) string {
 	var canonicalRequest string
 	if param.IsSignUrl {
 		queryList := make(url.Values)
@@ -210,7 +303,7 @@ func hashedCanonicalRequestV4(param RequestParam, meta *metadata, requestSignMap
 		canonicalHeaders := getCanonicalHeaders(param, meta, requestSignMap)
 		canonicalRequest = concat("\n", param.Method, normuri(param.Path), normquery(param.QueryList), canonicalHeaders, meta.signedHeaders, bodyHash)
 	}
-	return hashSHA256([]byte(canonicalRequest))
+	return canonicalRequest
 }
 
 func getCanonicalHeaders(param RequestParam, meta *metadata, requestSignMap map[string][]string) string {
@@ -0,0 +1,85 @@
+package volcano
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSignReadSeeker(t *testing.T) {
+	const prefix, payload = "skip", `{"UserName":"alice"}`
+
+	body := strings.NewReader(prefix + payload)
+	if _, err := body.Seek(int64(len(prefix)), io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := testCredentials.SignReadSeeker(newTestRequest(http.MethodPost, "Action=CreateUser&Version=2018-01-01", ""), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 计算哈希后回到原来的位置
+	if offset, _ := body.Seek(0, io.SeekCurrent); offset != int64(len(prefix)) {
+		t.Errorf("body offset = %d, want %d", offset, len(prefix))
+	}
+	if r.ContentLength != int64(len(payload)) {
+		t.Errorf("ContentLength = %d, want %d", r.ContentLength, len(payload))
+	}
+	if err = testCredentials.Verify(r); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+
+	// 重试时GetBody返回完整的请求体
+	rc, err := r.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(rc); string(b) != payload {
+		t.Errorf("GetBody() = %q, want %q", b, payload)
+	}
+}
+
+func TestSignWithContentHash(t *testing.T) {
+	const payload = `{"UserName":"alice"}`
+	hash, err := HashPayload(strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		hash     string
+		unsigned bool
+		part     SignaturePart
+	}{
+		{name: "precomputed hash", hash: hash},
+		{name: "wrong hash", hash: strings.Repeat("0", 64), part: SignaturePartBody},
+		{name: "unsigned payload", hash: UnsignedPayload, unsigned: true},
+		{name: "unsigned payload not allowed", hash: UnsignedPayload, part: SignaturePartBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testCredentials.SignWithContentHash(newTestRequest(http.MethodPost, "Action=CreateUser&Version=2018-01-01", payload), tt.hash)
+			if got := r.Header.Get("X-Content-Sha256"); got != tt.hash {
+				t.Errorf("X-Content-Sha256 = %q, want %q", got, tt.hash)
+			}
+
+			// 签名时不读取请求体
+			if b, _ := io.ReadAll(r.Body); string(b) != payload {
+				t.Fatalf("body = %q, want %q", b, payload)
+			}
+			r.Body = io.NopCloser(strings.NewReader(payload))
+
+			v := Verifier{
+				Lookup: func(ak string) (Credentials, bool) {
+					return testCredentials, ak == testCredentials.AccessKeyID
+				},
+				AllowUnsignedPayload: tt.unsigned,
+			}
+			checkSignatureError(t, v.Verify(r), tt.part)
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package volcano
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxSkew 验签时允许的默认时间偏差
+const DefaultMaxSkew = 15 * time.Minute
+
+// SignaturePart 验签失败时不匹配的部分
+type SignaturePart string
+
+const (
+	SignaturePartAuthorization SignaturePart = "Authorization" // 签名信息缺失或格式错误
+	SignaturePartAlgorithm     SignaturePart = "Algorithm"     // 签名算法不支持
+	SignaturePartAccessKey     SignaturePart = "AccessKey"     // AccessKey不存在
+	SignaturePartScope         SignaturePart = "Scope"         // 凭证范围（日期、地域、服务）不匹配
+	SignaturePartDate          SignaturePart = "Date"          // 签名时间缺失或超出允许的时间窗口
+	SignaturePartExpires       SignaturePart = "Expires"       // 预签名url有效期格式错误或已过期
+	SignaturePartSecurityToken SignaturePart = "SecurityToken" // 临时凭证token不匹配
+	SignaturePartSignedHeaders SignaturePart = "SignedHeaders" // 参与签名的header缺失或不完整
+	SignaturePartSignedQueries SignaturePart = "SignedQueries" // 参与签名的query缺失或存在未签名的query
+	SignaturePartBody          SignaturePart = "Body"          // 请求体哈希不匹配
+	SignaturePartSignature     SignaturePart = "Signature"     // 签名值不匹配
+)
+
+// SignatureError 验签失败，Part标识不匹配的部分
+type SignatureError struct {
+	Part SignaturePart
+	Msg  string
+
+	// Expected 服务端计算的签名中间结果，仅Part为Signature时不为空，可与客户端的SignatureDebug对比
+	Expected *SignatureDebug
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("signature mismatch(%s): %s", e.Part, e.Msg)
+}
+
+func signatureError(part SignaturePart, format string, args ...any) error {
+	return &SignatureError{Part: part, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Verifier 服务端验签，支持Authorization header和X-Credential/X-Signature query两种签名方式
+type Verifier struct {
+	// Service、Region 校验凭证范围中的服务和地域，为空时使用Lookup返回的凭证中的值
+	Service string
+	Region  string
+
+	// Lookup 根据AccessKeyID查找密钥，未找到时返回false
+	Lookup func(accessKeyID string) (Credentials, bool)
+
+	// MaxSkew 签名时间与当前时间允许的最大偏差，为0时使用DefaultMaxSkew
+	MaxSkew time.Duration
+
+	// AllowUnsignedPayload 是否接受X-Content-Sha256为UnsignedPayload的请求
+	AllowUnsignedPayload bool
+
+	// Now 服务端当前时间，为空时使用本地时间，可用于模拟时间偏差
+	Now func() time.Time
+}
+
+// Verify 使用当前密钥校验请求签名
+func (c Credentials) Verify(request *http.Request) error {
+	v := Verifier{
+		Service: c.Service,
+		Region:  c.Region,
+		Lookup: func(accessKeyID string) (Credentials, bool) {
+			return c, accessKeyID == c.AccessKeyID
+		},
+	}
+	return v.Verify(request)
+}
+
+// Verify 校验请求签名，失败时返回*SignatureError
+func (v *Verifier) Verify(request *http.Request) error {
+	if request.URL.Query().Has("X-Signature") {
+		return v.verifyQuery(request)
+	}
+	return v.verifyHeader(request)
+}
+
+// signedInfo 请求中携带的签名信息
+type signedInfo struct {
+	algorithm   string
+	accessKeyID string
+	scopeDate   string
+	region      string
+	service     string
+	xDate       string
+	token       string
+	signature   string
+	expires     time.Duration
+}
+
+func (v *Verifier) verifyHeader(request *http.Request) error {
+	auth := request.Header.Get("Authorization")
+	if auth == "" {
+		return signatureError(SignaturePartAuthorization, "missing authorization")
+	}
+
+	info, signedHeaders, err := parseAuthHeaderV4(auth)
+	if err != nil {
+		return err
+	}
+	info.xDate = request.Header.Get("X-Date")
+	info.token = request.Header.Get("X-Security-Token")
+
+	credentials, date, err := v.check(info)
+	if err != nil {
+		return err
+	}
+
+	headers := strings.Split(signedHeaders, ";")
+	for _, required := range []string{"host", "x-date", "x-content-sha256"} {
+		if !slices.Contains(headers, required) {
+			return signatureError(SignaturePartSignedHeaders, "%s is not signed", required)
+		}
+	}
+	if info.token != "" && !slices.Contains(headers, "x-security-token") {
+		return signatureError(SignaturePartSignedHeaders, "x-security-token is not signed")
+	}
+
+	requestSignMap := make(map[string][]string)
+	for _, key := range headers {
+		if key == "host" {
+			requestSignMap["Host"] = []string{request.Host}
+			continue
+		}
+		values := request.Header.Values(key)
+		if len(values) == 0 {
+			return signatureError(SignaturePartSignedHeaders, "missing signed header %s", key)
+		}
+		requestSignMap[http.CanonicalHeaderKey(key)] = values
+	}
+
+	bodyHash := request.Header.Get("X-Content-Sha256")
+	if bodyHash == UnsignedPayload {
+		if !v.AllowUnsignedPayload {
+			return signatureError(SignaturePartBody, "unsigned payload is not allowed")
+		}
+	} else if actual := hashSHA256(readAndReplaceBody(request)); actual != bodyHash {
+		return signatureError(SignaturePartBody, "content sha256 mismatched")
+	}
+
+	path := request.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	requestParam := RequestParam{
+		IsSignUrl: false,
+		Host:      request.Host,
+		Path:      path,
+		Method:    request.Method,
+		Date:      date,
+		QueryList: request.URL.Query(),
+		Headers:   request.Header,
+	}
+	meta := getMetaData(credentials, info.scopeDate)
+	expected := signatureDebugV4(requestParam, meta, credentials.SecretAccessKey, info.xDate, requestSignMap, bodyHash)
+	if meta.signedHeaders != signedHeaders {
+		return signatureError(SignaturePartSignedHeaders, "unexpected signed headers %s", signedHeaders)
+	}
+	if !hmac.Equal([]byte(expected.Signature), []byte(info.signature)) {
+		return &SignatureError{Part: SignaturePartSignature, Msg: "signature mismatched", Expected: &expected}
+	}
+	return nil
+}
+
+func (v *Verifier) verifyQuery(request *http.Request) error {
+	query := request.URL.Query()
+
+	info, err := parseQueryV4(query)
+	if err != nil {
+		return err
+	}
+
+	credentials, date, err := v.check(info)
+	if err != nil {
+		return err
+	}
+
+	signedQueries := strings.Split(query.Get("X-SignedQueries"), ";")
+	for _, required := range []string{"X-Date", "X-Credential", "X-Algorithm", "X-SignedHeaders", "X-SignedQueries"} {
+		if !slices.Contains(signedQueries, required) {
+			return signatureError(SignaturePartSignedQueries, "%s is not signed", required)
+		}
+	}
+	for key := range query {
+		if key != "X-Signature" && !slices.Contains(signedQueries, key) {
+			return signatureError(SignaturePartSignedQueries, "%s is not signed", key)
+		}
+	}
+
+	requestSignMap := make(map[string][]string)
+	for _, key := range signedQueries {
+		values, ok := query[key]
+		if !ok {
+			return signatureError(SignaturePartSignedQueries, "missing signed query %s", key)
+		}
+		requestSignMap[key] = values
+	}
+
+	requestParam := RequestParam{
+		IsSignUrl: true,
+		Host:      request.Host,
+		Path:      request.URL.Path,
+		Method:    request.Method,
+		Date:      date,
+		QueryList: query,
+		Headers:   request.Header,
+	}
+	meta := getMetaData(credentials, info.scopeDate)
+	meta.signedHeaders = query.Get("X-SignedHeaders")
+	expected := signatureDebugV4(requestParam, meta, credentials.SecretAccessKey, info.xDate, requestSignMap, hashSHA256([]byte{}))
+	if !hmac.Equal([]byte(expected.Signature), []byte(info.signature)) {
+		return &SignatureError{Part: SignaturePartSignature, Msg: "signature mismatched", Expected: &expected}
+	}
+	return nil
+}
+
+// check 校验算法、凭证范围、签名时间以及临时凭证token，返回用于计算签名的密钥
+func (v *Verifier) check(info signedInfo) (Credentials, time.Time, error) {
+	if info.algorithm != "HMAC-SHA256" {
+		return Credentials{}, time.Time{}, signatureError(SignaturePartAlgorithm, "unsupported algorithm %q", info.algorithm)
+	}
+
+	if v.Lookup == nil {
+		return Credentials{}, time.Time{}, signatureError(SignaturePartAccessKey, "no credentials")
+	}
+	credentials, ok := v.Lookup(info.accessKeyID)
+	if !ok {
+		return Credentials{}, time.Time{}, signatureError(SignaturePartAccessKey, "unknown access key %s", info.accessKeyID)
+	}
+
+	service, region := v.Service, v.Region
+	if service == "" {
+		service = credentials.Service
+	}
+	if region == "" {
+		region = credentials.Region
+	}
+	if info.service != service {
+		return Credentials{}, time.Time{}, signatureError(SignaturePartScope, "service mismatched: %s", info.service)
+	}
+	if info.region != region {
+		return Credentials{}, time.Time{}, signatureError(SignaturePartScope, "region mismatched: %s", info.region)
+	}
+	credentials.Service, credentials.Region = service, region
+
+	date, err := time.Parse(timeFormatV4, info.xDate)
+	if err != nil {
+		return Credentials{}, time.Time{}, signatureError(SignaturePartDate, "invalid date %q", info.xDate)
+	}
+	if tsDateV4(info.xDate) != info.scopeDate {
+		return Credentials{}, time.Time{}, signatureError(SignaturePartScope, "date mismatched: %s", info.scopeDate)
+	}
+
+	maxSkew := v.MaxSkew
+	if maxSkew == 0 {
+		maxSkew = DefaultMaxSkew
+	}
+	now := now
+	if v.Now != nil {
+		now = v.Now
+	}
+	if info.expires > 0 {
+		// 预签名url在有效期内均可使用，仅限制签名时间不能过于超前
+		if date.Sub(now()) > maxSkew {
+			return Credentials{}, time.Time{}, signatureError(SignaturePartDate, "date %s out of range", info.xDate)
+		}
+		if now().After(date.Add(info.expires)) {
+			return Credentials{}, time.Time{}, signatureError(SignaturePartExpires, "presigned url expired at %s", date.Add(info.expires).Format(time.RFC3339))
+		}
+	} else if skew := now().Sub(date); skew > maxSkew || skew < -maxSkew {
+		return Credentials{}, time.Time{}, signatureError(SignaturePartDate, "date %s out of range", info.xDate)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(info.token), []byte(credentials.SessionToken)) != 1 {
+		return Credentials{}, time.Time{}, signatureError(SignaturePartSecurityToken, "security token mismatched")
+	}
+
+	return credentials, date, nil
+}
+
+// parseAuthHeaderV4 解析Authorization header，返回签名信息和参与签名的header列表
+func parseAuthHeaderV4(auth string) (signedInfo, string, error) {
+	var info signedInfo
+
+	algorithm, fields, ok := strings.Cut(auth, " ")
+	if !ok {
+		return info, "", signatureError(SignaturePartAuthorization, "malformed authorization")
+	}
+	info.algorithm = algorithm
+
+	var credential, signedHeaders string
+	for _, field := range strings.Split(fields, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch k {
+		case "Credential":
+			credential = v
+		case "SignedHeaders":
+			signedHeaders = v
+		case "Signature":
+			info.signature = v
+		}
+	}
+	if credential == "" || signedHeaders == "" || info.signature == "" {
+		return info, "", signatureError(SignaturePartAuthorization, "malformed authorization")
+	}
+
+	if err := parseCredentialV4(credential, &info); err != nil {
+		return info, "", err
+	}
+	return info, signedHeaders, nil
+}
+
+// parseQueryV4 解析url query中的签名信息
+func parseQueryV4(query url.Values) (signedInfo, error) {
+	info := signedInfo{
+		algorithm: query.Get("X-Algorithm"),
+		xDate:     query.Get("X-Date"),
+		token:     query.Get("X-Security-Token"),
+		signature: query.Get("X-Signature"),
+	}
+	if err := parseCredentialV4(query.Get("X-Credential"), &info); err != nil {
+		return info, err
+	}
+
+	if query.Has("X-Expires") {
+		seconds, err := strconv.ParseInt(query.Get("X-Expires"), 10, 64)
+		if err != nil || seconds <= 0 {
+			return info, signatureError(SignaturePartExpires, "invalid expires %q", query.Get("X-Expires"))
+		}
+		info.expires = time.Duration(seconds) * time.Second
+	}
+	return info, nil
+}
+
+// parseCredentialV4 解析 AccessKeyID/Date/Region/Service/request 格式的凭证
+func parseCredentialV4(credential string, info *signedInfo) error {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[4] != "request" {
+		return signatureError(SignaturePartAuthorization, "malformed credential %q", credential)
+	}
+	info.accessKeyID, info.scopeDate, info.region, info.service = parts[0], parts[1], parts[2], parts[3]
+	return nil
+}
@@ -0,0 +1,208 @@
+package volcano
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCredentials = Credentials{
+	AccessKeyID:     "AKTEST",
+	SecretAccessKey: "secret",
+	Service:         "iam",
+	Region:          "cn-north-1",
+}
+
+func newTestRequest(method, query, body string) *http.Request {
+	return httptest.NewRequest(method, "https://open.volcengineapi.com/?"+query, strings.NewReader(body))
+}
+
+func TestVerifyHeader(t *testing.T) {
+	sts := testCredentials
+	sts.SessionToken = "session"
+
+	tests := []struct {
+		name     string
+		signer   Credentials
+		verifier Credentials
+		sign     func(c Credentials, r *http.Request) *http.Request
+		tamper   func(r *http.Request)
+		unsigned bool
+		now      time.Duration // 服务端时间相对签名时间的偏差
+		part     SignaturePart // 为空时期望验签通过
+	}{
+		{name: "ok"},
+		{name: "session token", signer: sts, verifier: sts},
+		{name: "wrong secret key", signer: Credentials{AccessKeyID: "AKTEST", SecretAccessKey: "other", Service: "iam", Region: "cn-north-1"}, part: SignaturePartSignature},
+		{name: "unknown access key", signer: Credentials{AccessKeyID: "AKOTHER", SecretAccessKey: "secret", Service: "iam", Region: "cn-north-1"}, part: SignaturePartAccessKey},
+		{name: "wrong service", signer: Credentials{AccessKeyID: "AKTEST", SecretAccessKey: "secret", Service: "sts", Region: "cn-north-1"}, part: SignaturePartScope},
+		{name: "missing security token", verifier: sts, part: SignaturePartSecurityToken},
+		{
+			name:   "tampered header",
+			tamper: func(r *http.Request) { r.Header.Set("Content-Type", "text/plain") },
+			part:   SignaturePartSignature,
+		},
+		{
+			name:   "tampered query",
+			tamper: func(r *http.Request) { r.URL.RawQuery = "Action=DeleteUser&Version=2018-01-01" },
+			part:   SignaturePartSignature,
+		},
+		{
+			name:   "tampered body",
+			tamper: func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader(`{"UserName":"root"}`)) },
+			part:   SignaturePartBody,
+		},
+		{
+			name:   "missing authorization",
+			tamper: func(r *http.Request) { r.Header.Del("Authorization") },
+			part:   SignaturePartAuthorization,
+		},
+		{name: "skew within range", now: 10 * time.Minute},
+		{name: "clock ahead", now: 20 * time.Minute, part: SignaturePartDate},
+		{name: "clock behind", now: -20 * time.Minute, part: SignaturePartDate},
+		{
+			name: "unsigned payload not allowed",
+			sign: Credentials.SignUnsignedPayload,
+			part: SignaturePartBody,
+		},
+		{
+			name:     "unsigned payload allowed",
+			sign:     Credentials.SignUnsignedPayload,
+			unsigned: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, verifier := tt.signer, tt.verifier
+			if signer == (Credentials{}) {
+				signer = testCredentials
+			}
+			if verifier == (Credentials{}) {
+				verifier = testCredentials
+			}
+			sign := tt.sign
+			if sign == nil {
+				sign = Credentials.Sign
+			}
+
+			r := sign(signer, newTestRequest(http.MethodPost, "Action=ListUsers&Version=2018-01-01", `{"Limit":10}`))
+			if tt.tamper != nil {
+				tt.tamper(r)
+			}
+
+			signed, _ := time.Parse(timeFormatV4, r.Header.Get("X-Date"))
+			v := Verifier{
+				Lookup: func(ak string) (Credentials, bool) {
+					return verifier, ak == verifier.AccessKeyID
+				},
+				AllowUnsignedPayload: tt.unsigned,
+				Now:                  func() time.Time { return signed.Add(tt.now) },
+			}
+			checkSignatureError(t, v.Verify(r), tt.part)
+		})
+	}
+}
+
+func TestVerifyPresignedUrl(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(r *http.Request)
+		now    time.Duration
+		part   SignaturePart
+	}{
+		{name: "ok"},
+		{name: "before expires", now: 59 * time.Second},
+		{name: "expired", now: 61 * time.Second, part: SignaturePartExpires},
+		{name: "signed in future", now: -20 * time.Minute, part: SignaturePartDate},
+		{
+			name:   "tampered query",
+			tamper: func(r *http.Request) { setQuery(r, "Action", "DeleteUser") },
+			part:   SignaturePartSignature,
+		},
+		{
+			name:   "extended expires",
+			tamper: func(r *http.Request) { setQuery(r, "X-Expires", "3600") },
+			now:    61 * time.Second,
+			part:   SignaturePartSignature,
+		},
+		{
+			name:   "unsigned query",
+			tamper: func(r *http.Request) { setQuery(r, "Extra", "1") },
+			part:   SignaturePartSignedQueries,
+		},
+		{
+			name:   "wrong signature",
+			tamper: func(r *http.Request) { setQuery(r, "X-Signature", strings.Repeat("0", 64)) },
+			part:   SignaturePartSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+			r := newTestRequest(http.MethodGet, "Action=ListUsers&Version=2018-01-01", "")
+			query, err := testCredentials.PresignUrl(r, time.Minute, date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.URL.RawQuery = query
+			if tt.tamper != nil {
+				tt.tamper(r)
+			}
+
+			v := Verifier{
+				Lookup: func(ak string) (Credentials, bool) {
+					return testCredentials, ak == testCredentials.AccessKeyID
+				},
+				Now: func() time.Time { return date.Add(tt.now) },
+			}
+			checkSignatureError(t, v.Verify(r), tt.part)
+		})
+	}
+}
+
+func TestVerifyExpectedDebug(t *testing.T) {
+	signer := Credentials{AccessKeyID: "AKTEST", SecretAccessKey: "other", Service: "iam", Region: "cn-north-1"}
+	r, debug := signer.SignDebug(newTestRequest(http.MethodPost, "Action=ListUsers&Version=2018-01-01", `{}`))
+
+	var se *SignatureError
+	if err := testCredentials.Verify(r); !errors.As(err, &se) || se.Expected == nil {
+		t.Fatalf("Verify() = %v, want SignatureError with Expected", err)
+	}
+	if se.Expected.CanonicalRequest != debug.CanonicalRequest {
+		t.Errorf("canonical request mismatched:\n%s\n%s", se.Expected.CanonicalRequest, debug.CanonicalRequest)
+	}
+	if se.Expected.Signature == debug.Signature {
+		t.Error("signature with different secret key should not match")
+	}
+}
+
+func setQuery(r *http.Request, key, value string) {
+	q := r.URL.Query()
+	q.Set(key, value)
+	r.URL.RawQuery = q.Encode()
+}
+
+func checkSignatureError(t *testing.T, err error, part SignaturePart) {
+	t.Helper()
+
+	if part == "" {
+		if err != nil {
+			t.Fatalf("Verify() = %v, want nil", err)
+		}
+		return
+	}
+
+	var se *SignatureError
+	if !errors.As(err, &se) {
+		t.Fatalf("Verify() = %v, want SignatureError(%s)", err, part)
+	}
+	if se.Part != part {
+		t.Fatalf("Verify() = %v, want part %s", err, part)
+	}
+}
@@ -2,24 +2,15 @@ package mega
 
 import (
 	"errors"
-	"fmt"
+	"github.com/jyinz/volcano-sdk"
+	"strconv"
 )
 
-type Error struct {
-	Code int
-	Msg  string
-}
+// Error OpenSpeech接口返回的错误，Code为声音复刻返回码
+type Error = volcano.Error
 
-func (e *Error) Error() string {
-	return fmt.Sprintf("code=%d, desc=%s", e.Code, e.Msg)
-}
-
-func (e *Error) Message() string {
-	if m := msgs[e.Code][1]; m != "" {
-		return m
-	}
-	return e.Msg
-}
+// _SpeechService 错误中的服务名
+const _SpeechService = "openspeech"
 
 var msgs = map[int][2]string{
 	1001: {"BadRequestError", "请求参数有误"},
@@ -40,18 +31,31 @@ var msgs = map[int][2]string{
 	1123: {"MaxUpload", "上传接口已经达到次数限制，目前同一个音色支持10次上传"},
 }
 
+// categories 声音复刻返回码分类
+var categories = map[int]volcano.Category{
+	1001: volcano.ErrInvalidParameter,
+	1106: volcano.ErrInvalidParameter,
+	1107: volcano.ErrNotFound,
+}
+
 func NewError(code int, msg string) error {
 	return &Error{
-		Code: code,
-		Msg:  msg,
+		Service:  _SpeechService,
+		Code:     strconv.Itoa(code),
+		Message:  msg,
+		Category: categories[code],
 	}
 }
 
 // Translate 翻译声音复刻返回码
 func Translate(err error) string {
-	var e = new(Error)
+	var e *Error
 	if errors.As(err, &e) {
-		return e.Message()
+		code, _ := strconv.Atoi(e.Code)
+		if m := msgs[code][1]; m != "" {
+			return m
+		}
+		return e.Message
 	}
 	return err.Error()
 }
@@ -0,0 +1,53 @@
+package mega
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jyinz/volcano-sdk"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		code      int
+		msg       string
+		want      volcano.Category
+		translate string
+	}{
+		{code: 1001, msg: "bad request", want: volcano.ErrInvalidParameter, translate: "请求参数有误"},
+		{code: 1106, msg: "duplicated", want: volcano.ErrInvalidParameter, translate: "SpeakerID重复"},
+		{code: 1107, msg: "not found", want: volcano.ErrNotFound, translate: "SpeakerID未找到"},
+		{code: 1123, msg: "max upload", translate: "上传接口已经达到次数限制，目前同一个音色支持10次上传"},
+		{code: 9999, msg: "unknown", translate: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			err := fmt.Errorf("upload failed: %w", NewError(tt.code, tt.msg))
+
+			// Error为volcano.Error的别名，两种写法均可取出错误
+			var me *Error
+			if !errors.As(err, &me) || me.Code != fmt.Sprint(tt.code) || me.Service != _SpeechService {
+				t.Fatalf("errors.As(*mega.Error) = %+v", me)
+			}
+			var ve *volcano.Error
+			if !errors.As(err, &ve) || ve != me {
+				t.Fatalf("errors.As(*volcano.Error) = %+v", ve)
+			}
+
+			if got := me.Classify(); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+			if tt.want != "" && !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(err, %q) = false", tt.want)
+			}
+			if got := Translate(err); got != tt.translate {
+				t.Errorf("Translate() = %q, want %q", got, tt.translate)
+			}
+		})
+	}
+
+	if got := Translate(errors.New("plain")); got != "plain" {
+		t.Errorf("Translate(plain) = %q", got)
+	}
+}
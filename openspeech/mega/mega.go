@@ -7,16 +7,19 @@ import (
 	"fmt"
 	"github.com/jyinz/volcano-sdk"
 	"io"
+	"iter"
+	"log/slog"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
-const (
-	_Scheme = "https"
-	_Host   = "openspeech.bytedance.com"
-)
+// _Endpoint 默认的OpenSpeech地址
+var _Endpoint = volcano.Endpoint{
+	Scheme: "https",
+	Host:   "openspeech.bytedance.com",
+}
 
 //go:generate stringer -type TrainingStatus
 type TrainingStatus int
@@ -81,6 +84,15 @@ func (r StatusResponse) TrainingCount() int {
 type OpenSpeech struct {
 	AccessToken string
 	AppID       string
+	Endpoint    volcano.Endpoint // OpenSpeech地址，未配置的字段使用默认值
+	HTTPClient  *http.Client     // 为空时使用http.DefaultClient
+
+	Retry       volcano.RetryPolicy // 重试策略，为空时不重试
+	RetryUpload bool                // 上传接口非幂等，为true时才按照Retry重试
+	Limiter     *volcano.Limiter    // 按接口路径限流，为空时不限流
+
+	Instrumenter volcano.Instrumenter // 记录调用的trace和metrics，为空时不记录
+	Logger       *slog.Logger         // 以Debug级别记录请求和返回信息，为空时不输出日志
 
 	openapi *OpenApi
 }
@@ -89,7 +101,8 @@ type OpenSpeech struct {
 func (c *OpenSpeech) Upload(ctx context.Context, ur *UploadRequest) (string, error) {
 	ur.AppID = c.AppID
 
-	rb, err := c.do(ctx, "/api/v1/mega_tts/audio/upload", ur)
+	// 上传接口非幂等，默认不重试
+	rb, err := c.do(ctx, "/api/v1/mega_tts/audio/upload", ur, c.RetryUpload)
 	if err != nil {
 		return "", err
 	}
@@ -108,7 +121,7 @@ func (c *OpenSpeech) Upload(ctx context.Context, ur *UploadRequest) (string, err
 func (c *OpenSpeech) Status(ctx context.Context, mr *StatusRequest) (*StatusResponse, error) {
 	mr.AppID = c.AppID
 
-	rb, err := c.do(ctx, "/api/v1/mega_tts/status", mr)
+	rb, err := c.do(ctx, "/api/v1/mega_tts/status", mr, true)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +142,20 @@ func (c *OpenSpeech) ListSpeakers(ctx context.Context, blr *BatchListMegaTTSTrai
 	return c.openapi.BatchListMegaTTSTrainStatus(ctx, blr)
 }
 
+// Speakers 遍历所有音色，使用NextToken自动翻页
+func (c *OpenSpeech) Speakers(ctx context.Context, blr BatchListMegaTTSTrainStatusRequest) iter.Seq2[SpeakerTrainStatus, error] {
+	blr.AppID = c.AppID
+
+	return c.openapi.IterMegaTTSTrainStatus(ctx, blr)
+}
+
+// AllSpeakers 获取所有音色，maxItems大于0时最多返回maxItems个
+func (c *OpenSpeech) AllSpeakers(ctx context.Context, blr BatchListMegaTTSTrainStatusRequest, maxItems int) ([]SpeakerTrainStatus, error) {
+	blr.AppID = c.AppID
+
+	return c.openapi.CollectMegaTTSTrainStatus(ctx, blr, maxItems)
+}
+
 // ActivateSpeakers 激活音色，即锁定使音色不再能够训练
 func (c *OpenSpeech) ActivateSpeakers(ctx context.Context, ar *ActivateMegaTTSTrainStatusRequest) ([]SpeakerTrainStatus, error) {
 	ar.AppID = c.AppID
@@ -136,14 +163,37 @@ func (c *OpenSpeech) ActivateSpeakers(ctx context.Context, ar *ActivateMegaTTSTr
 	return c.openapi.ActivateMegaTTSTrainStatus(ctx, ar)
 }
 
-func (c *OpenSpeech) do(ctx context.Context, path string, body any) ([]byte, error) {
-	u := url.URL{
-		Scheme: _Scheme,
-		Host:   _Host,
-		Path:   path,
+// do 发送请求，retry为true时按照重试策略重试
+func (c *OpenSpeech) do(ctx context.Context, path string, body any, retry bool) (rb []byte, err error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal body failed: %w", err)
+	}
+
+	var policy volcano.RetryPolicy
+	if retry {
+		policy = c.Retry
 	}
 
-	b, _ := json.Marshal(body)
+	err = volcano.Retry(ctx, policy, func(ctx context.Context) (err error) {
+		rb, err = c.send(ctx, path, b)
+		return err
+	})
+	return rb, err
+}
+
+func (c *OpenSpeech) send(ctx context.Context, path string, b []byte) (_ []byte, err error) {
+	err = c.Limiter.Wait(ctx, _SpeechService, path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := volcano.StartSpan(ctx, c.Instrumenter, volcano.Operation{Kind: volcano.KindHTTP, Service: _SpeechService, Action: path})
+	var status int
+	defer func() { span.End(status, err) }()
+	span.AddBytes(len(b), 0)
+
+	u := c.Endpoint.Resolve(_Endpoint).URL(path)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(b))
 	if err != nil {
@@ -154,10 +204,24 @@ func (c *OpenSpeech) do(ctx context.Context, path string, body any) ([]byte, err
 	req.Header.Set("Authorization", "Bearer;"+c.AccessToken)
 	req.Header.Set("Resource-Id", "volc.megatts.voiceclone")
 
-	rsp, err := http.DefaultClient.Do(req)
+	log := volcano.NewLogger(c.Logger).With("service", _SpeechService, "action", path)
+	log.DebugContext(ctx, "volcano openspeech request", "url", req.URL.String(), "header", req.Header)
+
+	var (
+		start     = time.Now()
+		requestId string
+	)
+	defer func() {
+		log.DebugContext(ctx, "volcano openspeech response", "status", status, "request_id", requestId, "elapsed", time.Since(start), "error", err)
+	}()
+
+	rsp, err := c.client().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("do request failed: %w", err)
 	}
+	status = rsp.StatusCode
+	requestId = rsp.Header.Get("X-Tt-Logid")
+	span.FirstChunk()
 
 	defer rsp.Body.Close()
 
@@ -165,31 +229,62 @@ func (c *OpenSpeech) do(ctx context.Context, path string, body any) ([]byte, err
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	span.AddBytes(0, len(rb))
 
 	// 请求失败，解析错误原因
 	if rsp.StatusCode != http.StatusOK {
+		e := &Error{
+			StatusCode: rsp.StatusCode,
+			Service:    _SpeechService,
+			Action:     path,
+			Message:    string(rb),
+			RequestId:  requestId,
+		}
+
 		var ret BaseResponse
-		err = json.Unmarshal(rb, &ret)
-		if err != nil {
-			return nil, fmt.Errorf("parse base response failed: %w", err)
+		if json.Unmarshal(rb, &ret) == nil {
+			code := ret.BaseResp.StatusCode
+			e.Code, e.Message, e.Category = strconv.Itoa(code), ret.BaseResp.StatusMessage, categories[code]
 		}
 
-		return nil, fmt.Errorf("bad response: %w", NewError(ret.BaseResp.StatusCode, ret.BaseResp.StatusMessage))
+		return nil, fmt.Errorf("bad response: %w", e)
 	}
 
 	return rb, nil
 }
 
+func (c *OpenSpeech) client() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
 type Config struct {
 	volcano.Config `yaml:",inline"`
 	AccessToken    string `json:"access_token" yaml:"access_token"`
 	AppID          string `json:"app_id" yaml:"app_id"`
+
+	// SpeechEndpoint OpenSpeech地址，默认为 https://openspeech.bytedance.com
+	SpeechEndpoint volcano.Endpoint `json:"speech_endpoint" yaml:"speech_endpoint"`
+
+	// RetryUpload 上传音频失败时是否重试，上传接口非幂等，重试可能导致重复计次
+	RetryUpload bool `json:"retry_upload" yaml:"retry_upload"`
 }
 
 func New(cfg Config) *OpenSpeech {
+	openapi := NewOpenApi(cfg.Config)
+
 	return &OpenSpeech{
-		AccessToken: cfg.AccessToken,
-		AppID:       cfg.AppID,
-		openapi:     NewOpenApi(cfg.Config),
+		AccessToken:  cfg.AccessToken,
+		AppID:        cfg.AppID,
+		Endpoint:     cfg.SpeechEndpoint,
+		HTTPClient:   cfg.HTTPClient,
+		Retry:        openapi.Retry,
+		RetryUpload:  cfg.RetryUpload,
+		Limiter:      cfg.Limiter,
+		Instrumenter: cfg.Instrumenter,
+		Logger:       cfg.Logger,
+		openapi:      openapi,
 	}
 }
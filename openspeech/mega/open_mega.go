@@ -1,13 +1,8 @@
 package mega
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
 	"github.com/jyinz/volcano-sdk"
-	"io"
-	"net/http"
 )
 
 // open api info
@@ -16,7 +11,6 @@ const (
 	_Service = "speech_saas_prod"
 	_Region  = "cn-north-1"
 	_Version = "2023-11-07"
-	_Path    = "/"
 )
 
 type SpeakerState = string
@@ -51,17 +45,7 @@ type (
 )
 
 type (
-	Metadata struct {
-		RequestId string `json:"RequestId"`
-		Action    string `json:"Action"`
-		Version   string `json:"Version"`
-		Service   string `json:"Service"`
-		Region    string `json:"Region"`
-		Error     *struct {
-			Code    string `json:"Code"`
-			Message string `json:"Message"`
-		} `json:"Error,omitempty"`
-	}
+	Metadata = volcano.ResponseMetadata
 
 	SpeakerTrainStatus struct {
 		CreateTime  int64        `json:"CreateTime,omitempty"`
@@ -98,9 +82,8 @@ type OpenApi struct {
 // 如果SpeakerIDs为空则返回账号的AppID下所有的列表（有最大值限制1000）
 // 如果SpeakerIDs不为空则返回对应的结果，且结果总是包含输入的SpeakerID（即使查询不到它） (ps：经测试，若包含错误的SpeakerID会返回错误)
 func (c *OpenApi) LiseMegaTTSTrainStatus(ctx context.Context, lr *LiseMegaTTSTrainStatusRequest) ([]SpeakerTrainStatus, error) {
-	u := c.buildUrl("ListMegaTTSTrainStatus")
-
-	ls, err := c.post(ctx, u, lr)
+	var ls SpeakerList
+	err := c.Invoke(ctx, "ListMegaTTSTrainStatus", _Version, lr, &ls)
 	if err != nil {
 		return nil, err
 	}
@@ -111,9 +94,13 @@ func (c *OpenApi) LiseMegaTTSTrainStatus(ctx context.Context, lr *LiseMegaTTSTra
 // BatchListMegaTTSTrainStatus 查询已购买的音色状态；相比ListMegaTTSTrainStatus 增加了分页相关参数和返回；支持使用token和声明页数两种分页方式
 // 分页token在最后一页为空；分页token采用私有密钥进行加密
 func (c *OpenApi) BatchListMegaTTSTrainStatus(ctx context.Context, blr *BatchListMegaTTSTrainStatusRequest) (*SpeakerList, error) {
-	u := c.buildUrl("BatchListMegaTTSTrainStatus")
+	var ls SpeakerList
+	err := c.Invoke(ctx, "BatchListMegaTTSTrainStatus", _Version, blr, &ls)
+	if err != nil {
+		return nil, err
+	}
 
-	return c.post(ctx, u, blr)
+	return &ls, nil
 }
 
 // ActivateMegaTTSTrainStatus 激活 (activate) SpeakerID。目前已无需调用该接口即可进行tts合成。调用该接口后将无法继续训练，无论是否还有剩余的训练次数。
@@ -121,9 +108,8 @@ func (c *OpenApi) BatchListMegaTTSTrainStatus(ctx context.Context, blr *BatchLis
 // 如果输入的音色列表为空，那么返回字段不合法的错误OperationDenied.InvalidParameter
 // 距离音色可被访问可能会有分钟级别延迟
 func (c *OpenApi) ActivateMegaTTSTrainStatus(ctx context.Context, ar *ActivateMegaTTSTrainStatusRequest) ([]SpeakerTrainStatus, error) {
-	u := c.buildUrl("ActivateMegaTTSTrainStatus")
-
-	ls, err := c.post(ctx, u, ar)
+	var ls SpeakerList
+	err := c.Invoke(ctx, "ActivateMegaTTSTrainStatus", _Version, ar, &ls)
 	if err != nil {
 		return nil, err
 	}
@@ -131,60 +117,8 @@ func (c *OpenApi) ActivateMegaTTSTrainStatus(ctx context.Context, ar *ActivateMe
 	return ls.Statuses, nil
 }
 
-func (c *OpenApi) buildUrl(action string) string {
-	u := c.BuildUrl(_Path, _Version, action)
-
-	return u.String()
-}
-
-func (c *OpenApi) post(ctx context.Context, url string, body any) (*SpeakerList, error) {
-	b, _ := json.Marshal(body)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
-	if err != nil {
-		return nil, fmt.Errorf("bad request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	return do(c.Sign(req))
-}
-
-func do(req *http.Request) (*SpeakerList, error) {
-	rsp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("do request failed: %w", err)
-	}
-
-	defer rsp.Body.Close()
-
-	rb, err := io.ReadAll(rsp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var ret = new(ListResponse)
-	err = json.Unmarshal(rb, ret)
-	if err != nil {
-		return nil, fmt.Errorf("parse data failed: %w", err)
-	}
-
-	// 请求失败，解析错误原因
-	if _err := ret.ResponseMetadata.Error; _err != nil {
-		return nil, fmt.Errorf("response error(%s): code = %s, desc = %s", rsp.Status, _err.Code, _err.Message)
-	}
-
-	return &ret.Result, nil
-}
-
 func NewOpenApi(cfg volcano.Config) *OpenApi {
 	return &OpenApi{
-		volcano.OpenApi{
-			Credentials: volcano.Credentials{
-				AccessKeyID:     cfg.AccessKey,
-				SecretAccessKey: cfg.SecretKey,
-				Service:         _Service,
-				Region:          _Region,
-			},
-		},
+		volcano.NewOpenApi(cfg, _Service, _Region),
 	}
 }
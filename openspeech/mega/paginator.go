@@ -0,0 +1,99 @@
+package mega
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+const (
+	// MaxPageSize BatchListMegaTTSTrainStatus单页最多返回的音色数
+	MaxPageSize = 100
+	// MaxSpeakerIDs ListMegaTTSTrainStatus单次查询最多返回的音色数，SpeakerIDs超过该数量时分批查询
+	MaxSpeakerIDs = 1000
+)
+
+// tokenPageRequest 使用NextToken分页的BatchListMegaTTSTrainStatus请求，
+// MaxResults需与NextToken一起使用，第一页也发送空的NextToken
+type tokenPageRequest struct {
+	LiseMegaTTSTrainStatusRequest
+	NextToken  string `json:"NextToken"`
+	MaxResults int    `json:"MaxResults"`
+}
+
+// IterMegaTTSTrainStatus 使用NextToken遍历BatchListMegaTTSTrainStatus的所有结果，
+// 从第一页起只使用NextToken、MaxResults分页，请求中的PageNumber、NextToken会被忽略，
+// PageSize、MaxResults为每页的数量，都为0时使用MaxPageSize；
+// SpeakerIDs超过MaxSpeakerIDs时分批查询；出错时返回错误并结束遍历
+func (c *OpenApi) IterMegaTTSTrainStatus(ctx context.Context, blr BatchListMegaTTSTrainStatusRequest) iter.Seq2[SpeakerTrainStatus, error] {
+	return func(yield func(SpeakerTrainStatus, error) bool) {
+		pageSize := blr.MaxResults
+		if pageSize <= 0 {
+			pageSize = blr.PageSize
+		}
+		if pageSize <= 0 || pageSize > MaxPageSize {
+			pageSize = MaxPageSize
+		}
+
+		for _, ids := range chunkSpeakerIDs(blr.SpeakerIDs) {
+			req := tokenPageRequest{
+				LiseMegaTTSTrainStatusRequest: LiseMegaTTSTrainStatusRequest{AppID: blr.AppID, SpeakerIDs: ids},
+				MaxResults:                    pageSize,
+			}
+
+			for {
+				var ls SpeakerList
+				err := c.Invoke(ctx, "BatchListMegaTTSTrainStatus", _Version, &req, &ls)
+				if err != nil {
+					yield(SpeakerTrainStatus{}, err)
+					return
+				}
+
+				for _, status := range ls.Statuses {
+					if !yield(status, nil) {
+						return
+					}
+				}
+
+				// 分页token在最后一页为空
+				if ls.NextToken == "" {
+					break
+				}
+				if ls.NextToken == req.NextToken {
+					yield(SpeakerTrainStatus{}, fmt.Errorf("next token %q repeated", ls.NextToken))
+					return
+				}
+				req.NextToken = ls.NextToken
+			}
+		}
+	}
+}
+
+// CollectMegaTTSTrainStatus 收集IterMegaTTSTrainStatus的所有结果，maxItems大于0时最多返回maxItems个，达到上限后不再请求后续的分页；出错时返回已收集的结果和错误
+func (c *OpenApi) CollectMegaTTSTrainStatus(ctx context.Context, blr BatchListMegaTTSTrainStatusRequest, maxItems int) ([]SpeakerTrainStatus, error) {
+	var statuses []SpeakerTrainStatus
+	for status, err := range c.IterMegaTTSTrainStatus(ctx, blr) {
+		if err != nil {
+			return statuses, err
+		}
+		statuses = append(statuses, status)
+		if maxItems > 0 && len(statuses) >= maxItems {
+			break
+		}
+	}
+	return statuses, nil
+}
+
+// chunkSpeakerIDs 按MaxSpeakerIDs拆分SpeakerIDs，为空时返回一个空批次以查询所有音色
+func chunkSpeakerIDs(ids []string) [][]string {
+	if len(ids) == 0 {
+		return [][]string{nil}
+	}
+
+	var chunks [][]string
+	for len(ids) > MaxSpeakerIDs {
+		chunks = append(chunks, ids[:MaxSpeakerIDs])
+		ids = ids[MaxSpeakerIDs:]
+	}
+	return append(chunks, ids)
+}
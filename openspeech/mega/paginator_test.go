@@ -0,0 +1,144 @@
+package mega
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jyinz/volcano-sdk/volcanotest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestIterMegaTTSTrainStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      BatchListMegaTTSTrainStatusRequest
+		pages    map[string]SpeakerList // 按请求的NextToken返回
+		maxItems int
+		want     []string
+		wantErr  bool
+		bodies   []string // 服务端收到的请求体
+	}{
+		{
+			name: "two pages",
+			req:  BatchListMegaTTSTrainStatusRequest{LiseMegaTTSTrainStatusRequest: LiseMegaTTSTrainStatusRequest{AppID: "app"}, PageNumber: 3, PageSize: 2},
+			pages: map[string]SpeakerList{
+				"":      {NextToken: "page2", Statuses: statuses("S_1", "S_2")},
+				"page2": {Statuses: statuses("S_3")},
+			},
+			want: []string{"S_1", "S_2", "S_3"},
+			bodies: []string{
+				`{"AppID":"app","NextToken":"","MaxResults":2}`,
+				`{"AppID":"app","NextToken":"page2","MaxResults":2}`,
+			},
+		},
+		{
+			name: "repeated token",
+			req:  BatchListMegaTTSTrainStatusRequest{LiseMegaTTSTrainStatusRequest: LiseMegaTTSTrainStatusRequest{AppID: "app"}},
+			pages: map[string]SpeakerList{
+				"":      {NextToken: "page2", Statuses: statuses("S_1")},
+				"page2": {NextToken: "page2", Statuses: statuses("S_2")},
+			},
+			want:    []string{"S_1", "S_2"},
+			wantErr: true,
+			bodies: []string{
+				`{"AppID":"app","NextToken":"","MaxResults":100}`,
+				`{"AppID":"app","NextToken":"page2","MaxResults":100}`,
+			},
+		},
+		{
+			// 达到maxItems后停止遍历，不再请求后续的分页
+			name: "stop early",
+			req:  BatchListMegaTTSTrainStatusRequest{LiseMegaTTSTrainStatusRequest: LiseMegaTTSTrainStatusRequest{AppID: "app"}, MaxResults: 2},
+			pages: map[string]SpeakerList{
+				"":      {NextToken: "page2", Statuses: statuses("S_1", "S_2")},
+				"page2": {Statuses: statuses("S_3")},
+			},
+			maxItems: 1,
+			want:     []string{"S_1"},
+			bodies:   []string{`{"AppID":"app","NextToken":"","MaxResults":2}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := volcanotest.NewServer()
+			defer s.Close()
+			s.Handle("BatchListMegaTTSTrainStatus", _Version, func(r *volcanotest.Request) *volcanotest.Response {
+				var req tokenPageRequest
+				_ = r.Decode(&req)
+				return &volcanotest.Response{Result: tt.pages[req.NextToken]}
+			})
+
+			c := NewOpenApi(s.Config())
+			got, err := c.CollectMegaTTSTrainStatus(context.Background(), tt.req, tt.maxItems)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CollectMegaTTSTrainStatus() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ids := speakerIDs(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("speakers = %v, want %v", ids, tt.want)
+			}
+
+			var bodies []string
+			for _, r := range s.RequestsFor("BatchListMegaTTSTrainStatus") {
+				bodies = append(bodies, string(r.Body))
+			}
+			if !reflect.DeepEqual(bodies, tt.bodies) {
+				t.Errorf("request bodies = %v, want %v", bodies, tt.bodies)
+			}
+		})
+	}
+}
+
+func TestIterMegaTTSTrainStatusChunks(t *testing.T) {
+	s := volcanotest.NewServer()
+	defer s.Close()
+	s.Handle("BatchListMegaTTSTrainStatus", _Version, func(r *volcanotest.Request) *volcanotest.Response {
+		var req tokenPageRequest
+		_ = r.Decode(&req)
+		return &volcanotest.Response{Result: SpeakerList{Statuses: statuses(req.SpeakerIDs...)}}
+	})
+
+	ids := make([]string, MaxSpeakerIDs+500)
+	for i := range ids {
+		ids[i] = "S_" + strconv.Itoa(i)
+	}
+
+	c := NewOpenApi(s.Config())
+	got, err := c.CollectMegaTTSTrainStatus(context.Background(), BatchListMegaTTSTrainStatusRequest{LiseMegaTTSTrainStatusRequest: LiseMegaTTSTrainStatusRequest{AppID: "app", SpeakerIDs: ids}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(speakerIDs(got), ids) {
+		t.Errorf("got %d speakers, want %d in order", len(got), len(ids))
+	}
+
+	// SpeakerIDs超过MaxSpeakerIDs时分两批查询
+	var sizes []int
+	for _, r := range s.RequestsFor("BatchListMegaTTSTrainStatus") {
+		var req tokenPageRequest
+		if err = json.Unmarshal(r.Body, &req); err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, len(req.SpeakerIDs))
+	}
+	if want := []int{MaxSpeakerIDs, 500}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("batch sizes = %v, want %v", sizes, want)
+	}
+}
+
+func statuses(ids ...string) []SpeakerTrainStatus {
+	ret := make([]SpeakerTrainStatus, len(ids))
+	for i, id := range ids {
+		ret[i] = SpeakerTrainStatus{SpeakerID: id, State: SpeakerStateSuccess}
+	}
+	return ret
+}
+
+func speakerIDs(ss []SpeakerTrainStatus) []string {
+	var ids []string
+	for _, s := range ss {
+		ids = append(ids, s.SpeakerID)
+	}
+	return ids
+}
@@ -1,12 +1,36 @@
 package tts
 
-import "fmt"
+import (
+	"github.com/jyinz/volcano-sdk"
+	"strconv"
+)
 
-type Error struct {
-	Code int32
-	Msg  string
+// Error 语音合成返回的错误，Code为合成返回码
+type Error = volcano.Error
+
+const (
+	_Service = "openspeech"
+	_Path    = "/api/v1/tts/ws_binary"
+	_CodeOK  = 3000
+)
+
+// categories 语音合成返回码分类
+var categories = map[int32]volcano.Category{
+	3001: volcano.ErrInvalidParameter, // 无效的请求
+	3003: volcano.ErrThrottled,        // 并发超限
+	3005: volcano.ErrThrottled,        // 后端服务忙
+	3010: volcano.ErrInvalidParameter, // 文本长度超限
+	3011: volcano.ErrInvalidParameter, // 无效文本
+	3050: volcano.ErrNotFound,         // 音色不存在
 }
 
-func (e Error) Error() string {
-	return fmt.Sprintf("code=%d, desc=%s", e.Code, e.Msg)
+func newError(code int32, msg, reqid string) error {
+	return &Error{
+		Service:   _Service,
+		Action:    _Path,
+		Code:      strconv.Itoa(int(code)),
+		Message:   msg,
+		RequestId: reqid,
+		Category:  categories[code],
+	}
 }
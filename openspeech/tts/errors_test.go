@@ -0,0 +1,46 @@
+package tts
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jyinz/volcano-sdk"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		code int32
+		want volcano.Category
+	}{
+		{code: 3001, want: volcano.ErrInvalidParameter},
+		{code: 3003, want: volcano.ErrThrottled},
+		{code: 3005, want: volcano.ErrThrottled},
+		{code: 3010, want: volcano.ErrInvalidParameter},
+		{code: 3011, want: volcano.ErrInvalidParameter},
+		{code: 3050, want: volcano.ErrNotFound},
+		{code: 3030},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			err := fmt.Errorf("synthesize failed: %w", newError(tt.code, "msg", "reqid"))
+
+			// Error为volcano.Error的别名，两种写法均可取出错误
+			var te *Error
+			if !errors.As(err, &te) || te.Code != fmt.Sprint(tt.code) || te.RequestId != "reqid" || te.Service != _Service {
+				t.Fatalf("errors.As(*tts.Error) = %+v", te)
+			}
+			var ve *volcano.Error
+			if !errors.As(err, &ve) || ve != te {
+				t.Fatalf("errors.As(*volcano.Error) = %+v", ve)
+			}
+
+			if got := te.Classify(); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+			if tt.want != "" && !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(err, %q) = false", tt.want)
+			}
+		})
+	}
+}
@@ -9,14 +9,19 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gorilla/websocket"
+	"github.com/jyinz/volcano-sdk"
 	"io"
+	"log/slog"
 	"net/http"
-	"net/url"
+	"strconv"
+	"time"
 )
 
-const (
-	_Host = "openspeech.bytedance.com"
-)
+// _Endpoint 默认的OpenSpeech websocket地址
+var _Endpoint = volcano.Endpoint{
+	Scheme: "wss",
+	Host:   "openspeech.bytedance.com",
+}
 
 type (
 	// APP 	应用相关配置
@@ -197,10 +202,7 @@ func (ret *SynResult) parse(b []byte) (err error) {
 		}
 
 	case 0xf: // error message from server
-		err = Error{
-			Code: int32(binary.BigEndian.Uint32(payload[0:4])),
-			Msg:  string(gzipDecompress(payload[8:])),
-		}
+		err = newError(int32(binary.BigEndian.Uint32(payload[0:4])), string(gzipDecompress(payload[8:])), "")
 	}
 
 	return
@@ -209,31 +211,70 @@ func (ret *SynResult) parse(b []byte) (err error) {
 type TTS struct {
 	AccessToken string
 	AppID       string
+	Endpoint    volcano.Endpoint  // OpenSpeech地址，未配置的字段使用默认值
+	Dialer      *websocket.Dialer // 为空时使用websocket.DefaultDialer
+	Limiter     *volcano.Limiter  // 限制合成请求速率和同时进行的合成会话数，为空时不限流
+
+	Instrumenter volcano.Instrumenter // 记录合成会话的trace和metrics，为空时不记录
+	Logger       *slog.Logger         // 以Debug级别记录合成会话信息，为空时不输出日志
 }
 
 // Synthesize 在线流式合成
-func (c *TTS) Synthesize(ctx context.Context, sr SynRequest, cb func(SynResult)) error {
-	u := url.URL{Scheme: "wss", Host: _Host, Path: "/api/v1/tts/ws_binary"}
+func (c *TTS) Synthesize(ctx context.Context, sr SynRequest, cb func(SynResult)) (err error) {
+	err = c.Limiter.Wait(ctx, _Service, _Path)
+	if err != nil {
+		return err
+	}
+
+	release, err := c.Limiter.AcquireStream(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx, span := volcano.StartSpan(ctx, c.Instrumenter, volcano.Operation{Kind: volcano.KindWebSocket, Service: _Service, Action: _Path})
+	var status int
+	defer func() { span.End(status, err) }()
+
+	u := c.Endpoint.Resolve(_Endpoint).URL(_Path)
 	header := http.Header{"Authorization": []string{fmt.Sprintf("Bearer;%s", c.AccessToken)}}
 
-	conn, rsp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	log := volcano.NewLogger(c.Logger).With("service", _Service, "action", _Path, "reqid", sr.Request.Reqid)
+	log.DebugContext(ctx, "volcano tts session start", "url", u.String(), "header", header, "voice_type", sr.Audio.VoiceType)
+
+	start := time.Now()
+	defer func() {
+		log.DebugContext(ctx, "volcano tts session end", "status", status, "elapsed", time.Since(start), "error", err)
+	}()
+
+	conn, rsp, err := c.dialer().DialContext(ctx, u.String(), header)
 	if err != nil {
 		if errors.Is(err, websocket.ErrBadHandshake) {
 			defer rsp.Body.Close()
 			b, _ := io.ReadAll(rsp.Body)
-			return fmt.Errorf("%w, reason:%s", err, string(b))
+			status = rsp.StatusCode
+			return fmt.Errorf("%w: %w", err, &Error{
+				StatusCode: rsp.StatusCode,
+				Service:    _Service,
+				Action:     _Path,
+				Message:    string(b),
+				RequestId:  rsp.Header.Get("X-Tt-Logid"),
+			})
 		}
 		return err
 	}
 	defer conn.Close()
 
 	// 发送请求
-	err = conn.WriteMessage(websocket.BinaryMessage, sr.wsMsg(c.AppID))
+	msg := sr.wsMsg(c.AppID)
+	err = conn.WriteMessage(websocket.BinaryMessage, msg)
 	if err != nil {
 		return fmt.Errorf("write message fail, err: %s", err.Error())
 	}
+	span.AddBytes(len(msg), 0)
 
 	// 接收返回
+	first := true
 	for {
 		var message []byte
 		_, message, err = conn.ReadMessage()
@@ -241,31 +282,72 @@ func (c *TTS) Synthesize(ctx context.Context, sr SynRequest, cb func(SynResult))
 			// log error
 			return err
 		}
+		span.AddBytes(0, len(message))
 
 		var ret SynResult
 		err = ret.parse(message)
 		if err != nil {
+			// 服务端返回的错误
+			var e *Error
+			if errors.As(err, &e) {
+				e.RequestId = sr.Request.Reqid
+				status, _ = strconv.Atoi(e.Code)
+				return e
+			}
 			return fmt.Errorf("parse message failed: %w", err)
 		}
 
+		if first && len(ret.Chunk) > 0 {
+			first = false
+			span.FirstChunk()
+		}
+
 		cb(ret)
 		if ret.end {
 			break
 		}
 	}
 
+	status = _CodeOK
 	return nil
 }
 
+func (c *TTS) dialer() *websocket.Dialer {
+	if c.Dialer != nil {
+		return c.Dialer
+	}
+	return websocket.DefaultDialer
+}
+
 type Config struct {
 	AccessToken string `json:"access_token" yaml:"access_token"`
 	AppID       string `json:"app_id" yaml:"app_id"`
+
+	// Endpoint OpenSpeech地址，默认为 wss://openspeech.bytedance.com
+	Endpoint volcano.Endpoint `json:"endpoint" yaml:"endpoint"`
+
+	// Dialer 建立websocket连接使用的dialer，为空时使用websocket.DefaultDialer
+	Dialer *websocket.Dialer `json:"-" yaml:"-"`
+
+	// Limiter 客户端限流器，可在多个客户端间共享，为空时不限流
+	Limiter *volcano.Limiter `json:"-" yaml:"-"`
+
+	// Instrumenter 记录合成会话的trace和metrics，为空时不记录
+	Instrumenter volcano.Instrumenter `json:"-" yaml:"-"`
+
+	// Logger 以Debug级别记录合成会话信息，access token会被脱敏，为空时不输出日志
+	Logger *slog.Logger `json:"-" yaml:"-"`
 }
 
 func New(cfg Config) *TTS {
 	return &TTS{
-		AccessToken: cfg.AccessToken,
-		AppID:       cfg.AppID,
+		AccessToken:  cfg.AccessToken,
+		AppID:        cfg.AppID,
+		Endpoint:     cfg.Endpoint,
+		Dialer:       cfg.Dialer,
+		Limiter:      cfg.Limiter,
+		Instrumenter: cfg.Instrumenter,
+		Logger:       cfg.Logger,
 	}
 }
 
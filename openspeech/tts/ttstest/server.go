@@ -0,0 +1,349 @@
+// Package ttstest 提供本地的OpenSpeech流式语音合成模拟服务，实现 /api/v1/tts/ws_binary 的二进制协议
+//
+//	srv := ttstest.NewServer()
+//	defer srv.Close()
+//
+//	srv.Handle(func(*tts.SynRequest) []ttstest.Frame {
+//		return []ttstest.Frame{ttstest.Audio([]byte("chunk1")), ttstest.Audio([]byte("chunk2"))}
+//	})
+//	err := tts.New(srv.Config()).Synthesize(ctx, sr, cb)
+package ttstest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/openspeech/tts"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// AccessToken 模拟服务默认接受的access token
+	AccessToken = "ttstest-token"
+
+	_Path = "/api/v1/tts/ws_binary"
+)
+
+// 服务端消息类型
+const (
+	_TypeAudio    = 0xb
+	_TypeFrontend = 0xc
+	_TypeError    = 0xf
+)
+
+// Frame 模拟服务下发的一帧数据，Audio、Frontend、Err只需设置其中一个
+type Frame struct {
+	Audio    []byte
+	Frontend *tts.Frontend
+	Err      *FrameError
+
+	// Delay 下发前等待的时间
+	Delay time.Duration
+	// Drop 为true时不下发数据，直接断开连接
+	Drop bool
+
+	// audio 由Audio生成，数据为空时也作为音频帧
+	audio bool
+}
+
+func (f Frame) isAudio() bool {
+	return f.audio || f.Audio != nil
+}
+
+// FrameError 服务端错误帧
+type FrameError struct {
+	Code    int32
+	Message string
+}
+
+// Audio 音频帧
+func Audio(chunk []byte) Frame {
+	return Frame{Audio: chunk, audio: true}
+}
+
+// Timestamps 时间戳帧
+func Timestamps(f tts.Frontend) Frame {
+	return Frame{Frontend: &f}
+}
+
+// Error 错误帧，下发后结束会话
+func Error(code int32, message string) Frame {
+	return Frame{Err: &FrameError{Code: code, Message: message}}
+}
+
+// Drop 断开连接
+func Drop() Frame {
+	return Frame{Drop: true}
+}
+
+// HandlerFunc 根据合成请求生成下发的数据帧；最后一个音频帧会被标记为结束帧，
+// 最后一帧不是音频帧时会补充一个空的结束帧；包含错误帧或断开时不补充
+type HandlerFunc func(sr *tts.SynRequest) []Frame
+
+// Request 模拟服务收到的合成请求
+type Request struct {
+	Header     http.Header
+	SynRequest tts.SynRequest
+}
+
+// Server 模拟的流式语音合成服务
+type Server struct {
+	*httptest.Server
+
+	// AccessToken 校验握手时的 Authorization: Bearer;token，为空时不校验
+	AccessToken string
+
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	handler  HandlerFunc
+	requests []*Request
+
+	seq atomic.Int64
+}
+
+// NewServer 启动模拟服务，默认将请求文本按 ChunkSize 切分后作为音频返回
+func NewServer() *Server {
+	s := &Server{AccessToken: AccessToken}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// ChunkSize 默认处理函数中每个音频帧的大小
+const ChunkSize = 16
+
+// Echo 默认处理函数：将请求文本按ChunkSize切分后作为音频返回
+func Echo(sr *tts.SynRequest) []Frame {
+	var (
+		text   = []byte(sr.Request.Text)
+		frames []Frame
+	)
+	for len(text) > ChunkSize {
+		frames = append(frames, Audio(text[:ChunkSize]))
+		text = text[ChunkSize:]
+	}
+	return append(frames, Audio(text))
+}
+
+// Handle 设置处理函数，为nil时使用Echo
+func (s *Server) Handle(h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handler = h
+}
+
+// Respond 所有请求固定下发frames
+func (s *Server) Respond(frames ...Frame) {
+	s.Handle(func(*tts.SynRequest) []Frame {
+		return frames
+	})
+}
+
+// Requests 返回收到的所有合成请求
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Request(nil), s.requests...)
+}
+
+// Endpoint 模拟服务的websocket地址
+func (s *Server) Endpoint() volcano.Endpoint {
+	u, _ := url.Parse(s.URL)
+	return volcano.Endpoint{Host: u.Host, Scheme: "ws"}
+}
+
+// Config 访问模拟服务的配置
+func (s *Server) Config() tts.Config {
+	return tts.Config{
+		AccessToken: s.AccessToken,
+		AppID:       "ttstest",
+		Endpoint:    s.Endpoint(),
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	logid := fmt.Sprintf("ttstest-%d", s.seq.Add(1))
+	w.Header().Set("X-Tt-Logid", logid)
+
+	if r.URL.Path != _Path {
+		http.Error(w, `{"code":3050,"message":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if s.AccessToken != "" && r.Header.Get("Authorization") != "Bearer;"+s.AccessToken {
+		http.Error(w, `{"code":3001,"message":"invalid access token"}`, http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, http.Header{"X-Tt-Logid": []string{logid}})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+
+	var sr tts.SynRequest
+	if err = decodeRequest(msg, &sr); err != nil {
+		_ = conn.WriteMessage(websocket.BinaryMessage, errorFrame(3001, err.Error()))
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, &Request{Header: r.Header.Clone(), SynRequest: sr})
+	h := s.handler
+	s.mu.Unlock()
+
+	if h == nil {
+		h = Echo
+	}
+	frames := h(&sr)
+
+	// 最后一个音频帧作为结束帧，包含错误帧或断开时在该帧结束会话
+	last, abort := -1, false
+	for i, f := range frames {
+		if f.Err != nil || f.Drop {
+			last, abort = -1, true
+			break
+		}
+		if f.isAudio() {
+			last = i
+		}
+	}
+	if !abort && (last != len(frames)-1 || len(frames) == 0) {
+		frames = append(frames, Audio([]byte{}))
+		last = len(frames) - 1
+	}
+
+	var seq int32
+	for i, f := range frames {
+		if f.Delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(f.Delay):
+			}
+		}
+
+		var b []byte
+		switch {
+		case f.Drop:
+			// 不发送close帧，模拟连接异常断开
+			_ = conn.NetConn().Close()
+			return
+		case f.Err != nil:
+			b = errorFrame(f.Err.Code, f.Err.Message)
+		case f.Frontend != nil:
+			b = frontendFrame(*f.Frontend)
+		default:
+			seq++
+			if i == last {
+				seq = -seq
+			}
+			b = audioFrame(seq, f.Audio)
+		}
+
+		if err = conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+			return
+		}
+		if f.Err != nil || i == last {
+			return
+		}
+	}
+}
+
+// decodeRequest 解析客户端请求：4字节header、4字节payload长度、gzip压缩的json
+func decodeRequest(msg []byte, sr *tts.SynRequest) error {
+	if len(msg) < 8 {
+		return errors.New("message too short")
+	}
+	headSize := int(msg[0]&0x0f) * 4
+	if msgType := msg[1] >> 4; msgType != 0x1 {
+		return fmt.Errorf("unexpected message type %#x", msgType)
+	}
+	if len(msg) < headSize+4 {
+		return errors.New("message too short")
+	}
+
+	size := int(binary.BigEndian.Uint32(msg[headSize:]))
+	payload := msg[headSize+4:]
+	if len(payload) != size {
+		return fmt.Errorf("payload size mismatched: %d != %d", len(payload), size)
+	}
+
+	if msg[2]&0x0f == 1 {
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		payload, err = io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return json.Unmarshal(payload, sr)
+}
+
+// audioFrame 音频帧：header、4字节序号（负数表示结束）、4字节长度、音频
+func audioFrame(seq int32, audio []byte) []byte {
+	flags := byte(0x1)
+	if seq < 0 {
+		flags = 0x3
+	}
+
+	b := make([]byte, 12+len(audio))
+	copy(b, []byte{0x11, _TypeAudio<<4 | flags, 0x00, 0x00})
+	binary.BigEndian.PutUint32(b[4:], uint32(seq))
+	binary.BigEndian.PutUint32(b[8:], uint32(len(audio)))
+	copy(b[12:], audio)
+	return b
+}
+
+// frontendFrame 时间戳帧：header、4字节长度、gzip压缩的json
+func frontendFrame(f tts.Frontend) []byte {
+	frontend, _ := json.Marshal(f)
+	payload, _ := json.Marshal(tts.FrontendMessage{Frontend: string(frontend)})
+	payload = gzipCompress(payload)
+
+	b := make([]byte, 8+len(payload))
+	copy(b, []byte{0x11, _TypeFrontend << 4, 0x11, 0x00})
+	binary.BigEndian.PutUint32(b[4:], uint32(len(payload)))
+	copy(b[8:], payload)
+	return b
+}
+
+// errorFrame 错误帧：header、4字节错误码、4字节长度、gzip压缩的错误信息
+func errorFrame(code int32, message string) []byte {
+	payload := gzipCompress([]byte(message))
+
+	b := make([]byte, 12+len(payload))
+	copy(b, []byte{0x11, _TypeError << 4, 0x01, 0x00})
+	binary.BigEndian.PutUint32(b[4:], uint32(code))
+	binary.BigEndian.PutUint32(b[8:], uint32(len(payload)))
+	copy(b[12:], payload)
+	return b
+}
+
+func gzipCompress(input []byte) []byte {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	_, _ = w.Write(input)
+	_ = w.Close()
+	return b.Bytes()
+}
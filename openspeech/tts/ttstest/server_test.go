@@ -0,0 +1,121 @@
+package ttstest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/openspeech/tts"
+	"github.com/jyinz/volcano-sdk/openspeech/tts/ttstest"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	const text = "hello ttstest, this text spans several chunks"
+
+	tests := []struct {
+		name      string
+		frames    []ttstest.Frame // 为nil时使用Echo
+		token     string
+		want      string
+		words     int
+		results   int // 不为0时校验回调次数，即收到的帧数
+		wantErr   func(err error) bool
+		wantCalls int
+	}{
+		{name: "echo", want: text, wantCalls: 1},
+		{
+			name:      "audio with timestamps",
+			frames:    []ttstest.Frame{ttstest.Audio([]byte("ab")), ttstest.Timestamps(tts.Frontend{Words: []tts.Word{{Word: "ab"}}}), ttstest.Audio([]byte("cd"))},
+			want:      "abcd",
+			words:     1,
+			wantCalls: 1,
+		},
+		{
+			// 未下发任何帧时补充空的结束帧
+			name:      "no frames",
+			frames:    []ttstest.Frame{},
+			results:   1,
+			wantCalls: 1,
+		},
+		{
+			// 空的音频帧作为结束帧，不再补充
+			name:      "empty audio as end frame",
+			frames:    []ttstest.Frame{ttstest.Audio([]byte("ab")), ttstest.Audio(nil)},
+			want:      "ab",
+			results:   2,
+			wantCalls: 1,
+		},
+		{
+			name:      "error frame",
+			frames:    []ttstest.Frame{ttstest.Audio([]byte("ab")), ttstest.Error(3003, "too many requests")},
+			want:      "ab",
+			wantErr:   func(err error) bool { return errors.Is(err, volcano.ErrThrottled) },
+			wantCalls: 1,
+		},
+		{
+			name:      "dropped connection",
+			frames:    []ttstest.Frame{ttstest.Audio([]byte("ab")), ttstest.Drop()},
+			want:      "ab",
+			wantErr:   func(err error) bool { return err != nil },
+			wantCalls: 1,
+		},
+		{
+			name:    "invalid access token",
+			token:   "wrong",
+			wantErr: func(err error) bool { return errors.Is(err, volcano.ErrAuthFailure) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := ttstest.NewServer()
+			defer s.Close()
+			if tt.frames != nil {
+				s.Respond(tt.frames...)
+			}
+
+			cfg := s.Config()
+			if tt.token != "" {
+				cfg.AccessToken = tt.token
+			}
+
+			var (
+				audio   bytes.Buffer
+				words   int
+				results int
+			)
+			sr := tts.SynRequest{Request: tts.Request{Reqid: "reqid", Text: text, Operation: "submit"}}
+			err := tts.New(cfg).Synthesize(context.Background(), sr, func(ret tts.SynResult) {
+				audio.Write(ret.Chunk)
+				words += len(ret.Frontend.Words)
+				results++
+			})
+
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("Synthesize() = %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Synthesize() = %v", err)
+			}
+			if audio.String() != tt.want {
+				t.Errorf("audio = %q, want %q", audio.String(), tt.want)
+			}
+			if words != tt.words {
+				t.Errorf("words = %d, want %d", words, tt.words)
+			}
+			if tt.results != 0 && results != tt.results {
+				t.Errorf("received %d frames, want %d", results, tt.results)
+			}
+
+			reqs := s.Requests()
+			if len(reqs) != tt.wantCalls {
+				t.Fatalf("server received %d requests, want %d", len(reqs), tt.wantCalls)
+			}
+			if len(reqs) > 0 && reqs[0].SynRequest.Request.Text != text {
+				t.Errorf("request text = %q", reqs[0].SynRequest.Request.Text)
+			}
+		})
+	}
+}
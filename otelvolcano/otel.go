@@ -0,0 +1,146 @@
+// Package otelvolcano 将SDK的调用观测接入OpenTelemetry；为独立的module，避免未使用时引入OpenTelemetry依赖
+package otelvolcano
+
+import (
+	"context"
+	"errors"
+	"github.com/jyinz/volcano-sdk"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const _ScopeName = "github.com/jyinz/volcano-sdk/otelvolcano"
+
+// Instrumenter 实现volcano.Instrumenter，为每次调用记录span，并记录耗时、首包时延、收发字节数
+type Instrumenter struct {
+	tracer trace.Tracer
+
+	duration   metric.Float64Histogram
+	firstChunk metric.Float64Histogram
+	bytes      metric.Int64Counter
+}
+
+// New 使用指定的TracerProvider和MeterProvider生成Instrumenter，为nil时使用otel的全局Provider
+func New(tp trace.TracerProvider, mp metric.MeterProvider) (*Instrumenter, error) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter(_ScopeName)
+
+	duration, err := meter.Float64Histogram("volcano.client.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of volcano API calls and sessions"))
+	if err != nil {
+		return nil, err
+	}
+
+	firstChunk, err := meter.Float64Histogram("volcano.client.first_chunk.duration",
+		metric.WithUnit("s"), metric.WithDescription("Time to the first response chunk"))
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := meter.Int64Counter("volcano.client.io",
+		metric.WithUnit("By"), metric.WithDescription("Bytes sent and received"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instrumenter{
+		tracer:     tp.Tracer(_ScopeName),
+		duration:   duration,
+		firstChunk: firstChunk,
+		bytes:      bytes,
+	}, nil
+}
+
+func (i *Instrumenter) Start(ctx context.Context, op volcano.Operation) (context.Context, volcano.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("volcano.kind", op.Kind),
+		attribute.String("volcano.service", op.Service),
+		attribute.String("volcano.action", op.Action),
+	}
+
+	ctx, s := i.tracer.Start(ctx, op.Service+" "+op.Action,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	return ctx, &span{
+		i:     i,
+		ctx:   ctx,
+		span:  s,
+		attrs: attrs,
+		start: time.Now(),
+	}
+}
+
+type span struct {
+	i     *Instrumenter
+	ctx   context.Context
+	span  trace.Span
+	attrs []attribute.KeyValue
+	start time.Time
+
+	first   sync.Once
+	out, in atomic.Int64
+}
+
+func (s *span) FirstChunk() {
+	s.first.Do(func() {
+		d := time.Since(s.start)
+		s.span.AddEvent("first_chunk")
+		s.i.firstChunk.Record(s.ctx, d.Seconds(), metric.WithAttributes(s.attrs...))
+	})
+}
+
+func (s *span) AddBytes(out, in int) {
+	s.out.Add(int64(out))
+	s.in.Add(int64(in))
+}
+
+func (s *span) End(status int, err error) {
+	attrs := append(s.attrs[:len(s.attrs):len(s.attrs)], attribute.Int("volcano.status_code", status))
+	if err != nil {
+		attrs = append(attrs, attribute.String("error.type", errorType(err)))
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+
+	out, in := s.out.Load(), s.in.Load()
+	s.span.SetAttributes(
+		attribute.Int("volcano.status_code", status),
+		attribute.Int64("volcano.bytes_out", out),
+		attribute.Int64("volcano.bytes_in", in),
+	)
+
+	s.i.duration.Record(s.ctx, time.Since(s.start).Seconds(), metric.WithAttributes(attrs...))
+	attrs = attrs[:len(attrs):len(attrs)]
+	s.i.bytes.Add(s.ctx, out, metric.WithAttributes(append(attrs, attribute.String("direction", "out"))...))
+	s.i.bytes.Add(s.ctx, in, metric.WithAttributes(append(attrs, attribute.String("direction", "in"))...))
+
+	s.span.End()
+}
+
+// errorType 错误分类，优先使用接口返回的错误码
+func errorType(err error) string {
+	var e *volcano.Error
+	if errors.As(err, &e) {
+		if c := e.Classify(); c != "" {
+			return string(c)
+		}
+		if e.Code != "" {
+			return e.Code
+		}
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return "canceled"
+	}
+	return "error"
+}
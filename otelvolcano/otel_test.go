@@ -0,0 +1,112 @@
+package otelvolcano
+
+import (
+	"context"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/volcanotest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"testing"
+)
+
+func TestInstrumenter(t *testing.T) {
+	s := volcanotest.NewServer()
+	defer s.Close()
+	s.Respond("ListUsers", "2018-01-01", map[string]int{"Total": 1})
+	s.RespondError("GetUser", "2018-01-01", http.StatusNotFound, "UserNotExist", "user not exist")
+
+	tests := []struct {
+		name       string
+		action     string
+		status     int
+		errorType  string // 为空时调用成功
+		spanStatus codes.Code
+	}{
+		{name: "ok", action: "ListUsers", status: http.StatusOK, spanStatus: codes.Unset},
+		{name: "error", action: "GetUser", status: http.StatusNotFound, errorType: string(volcano.ErrNotFound), spanStatus: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := tracetest.NewSpanRecorder()
+			reader := sdkmetric.NewManualReader()
+			i, err := New(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)), sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := s.Config()
+			cfg.Instrumenter = i
+			c := volcano.NewOpenApi(cfg, "iam", "cn-north-1")
+			err = c.Invoke(context.Background(), tt.action, "2018-01-01", nil, nil)
+			if (err != nil) != (tt.errorType != "") {
+				t.Fatalf("Invoke() = %v", err)
+			}
+
+			ended := spans.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(ended))
+			}
+			span := ended[0]
+			if span.Name() != "iam "+tt.action || span.Status().Code != tt.spanStatus {
+				t.Errorf("span = %q %v", span.Name(), span.Status())
+			}
+			attrs := attribute.NewSet(span.Attributes()...)
+			for k, want := range map[attribute.Key]attribute.Value{
+				"volcano.kind":        attribute.StringValue(volcano.KindOpenApi),
+				"volcano.service":     attribute.StringValue("iam"),
+				"volcano.action":      attribute.StringValue(tt.action),
+				"volcano.status_code": attribute.IntValue(tt.status),
+			} {
+				if got, _ := attrs.Value(k); got != want {
+					t.Errorf("span attribute %s = %v, want %v", k, got.Emit(), want.Emit())
+				}
+			}
+			if got, _ := attrs.Value("volcano.bytes_in"); got.AsInt64() <= 0 {
+				t.Errorf("volcano.bytes_in = %v, want > 0", got.Emit())
+			}
+
+			var rm metricdata.ResourceMetrics
+			if err = reader.Collect(context.Background(), &rm); err != nil {
+				t.Fatal(err)
+			}
+			metrics := make(map[string]metricdata.Aggregation)
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					metrics[m.Name] = m.Data
+				}
+			}
+
+			duration, ok := metrics["volcano.client.duration"].(metricdata.Histogram[float64])
+			if !ok || len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 1 {
+				t.Fatalf("volcano.client.duration = %+v", metrics["volcano.client.duration"])
+			}
+			dattrs := duration.DataPoints[0].Attributes
+			if got, _ := dattrs.Value("volcano.action"); got.AsString() != tt.action {
+				t.Errorf("duration volcano.action = %v", got.Emit())
+			}
+			if got, _ := dattrs.Value("error.type"); got.AsString() != tt.errorType {
+				t.Errorf("duration error.type = %q, want %q", got.AsString(), tt.errorType)
+			}
+
+			if first, ok := metrics["volcano.client.first_chunk.duration"].(metricdata.Histogram[float64]); !ok || len(first.DataPoints) != 1 {
+				t.Errorf("volcano.client.first_chunk.duration = %+v", metrics["volcano.client.first_chunk.duration"])
+			}
+
+			io, ok := metrics["volcano.client.io"].(metricdata.Sum[int64])
+			if !ok || len(io.DataPoints) != 2 {
+				t.Fatalf("volcano.client.io = %+v", metrics["volcano.client.io"])
+			}
+			for _, dp := range io.DataPoints {
+				if dir, _ := dp.Attributes.Value("direction"); dir.AsString() == "in" && dp.Value <= 0 {
+					t.Errorf("bytes in = %d, want > 0", dp.Value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package volcano
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// _MaxBuckets 令牌桶数量的上限，按调用方等动态key限流时超过后清理空闲的令牌桶
+const _MaxBuckets = 10000
+
+// Rate 令牌桶限速配置
+type Rate struct {
+	QPS   float64 `json:"qps" yaml:"qps"`     // 每秒产生的令牌数，小于等于0表示不限速
+	Burst int     `json:"burst" yaml:"burst"` // 桶容量，小于等于0时为1
+}
+
+// Limiter 客户端限流器：按接口令牌桶限速，并限制同时进行的websocket会话数；
+// 可在多个客户端实例间共享，nil表示不限流
+type Limiter struct {
+	// Default 未单独配置的接口使用的限速
+	Default Rate
+	// Rates 按接口配置的限速，key为 service/action，如 sami/GetToken、openspeech//api/v1/mega_tts/status
+	Rates map[string]Rate
+	// MaxStreams 同时进行的websocket会话上限，小于等于0表示不限制
+	MaxStreams int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	streams chan struct{}
+}
+
+// NewLimiter 生成限流器，rates为按接口配置的限速
+func NewLimiter(def Rate, rates map[string]Rate, maxStreams int) *Limiter {
+	return &Limiter{
+		Default:    def,
+		Rates:      rates,
+		MaxStreams: maxStreams,
+	}
+}
+
+// Wait 等待service/action的令牌，ctx结束时返回ctx的错误
+func (l *Limiter) Wait(ctx context.Context, service, action string) error {
+	if l == nil {
+		return nil
+	}
+
+	b := l.bucket(service + "/" + action)
+	if b == nil {
+		return nil
+	}
+
+	delay := b.reserve(now())
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		b.cancel()
+		return context.Cause(ctx)
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Allow 不等待地获取service/action的令牌，没有可用的令牌时返回false
+func (l *Limiter) Allow(service, action string) bool {
+	if l == nil {
+		return true
+	}
+
+	b := l.bucket(service + "/" + action)
+	if b == nil {
+		return true
+	}
+
+	if b.reserve(now()) > 0 {
+		b.cancel()
+		return false
+	}
+	return true
+}
+
+// AcquireStream 占用一个websocket会话，会话结束后需调用release释放；ctx结束时返回ctx的错误
+func (l *Limiter) AcquireStream(ctx context.Context) (release func(), err error) {
+	if l == nil || l.MaxStreams <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	if l.streams == nil {
+		l.streams = make(chan struct{}, l.MaxStreams)
+	}
+	streams := l.streams
+	l.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	case streams <- struct{}{}:
+		var once sync.Once
+		return func() {
+			once.Do(func() { <-streams })
+		}, nil
+	}
+}
+
+func (l *Limiter) bucket(key string) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.buckets[key]; ok {
+		return b
+	}
+
+	rate, ok := l.Rates[key]
+	if !ok {
+		rate = l.Default
+	}
+	if rate.QPS <= 0 {
+		return nil
+	}
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+	}
+	if len(l.buckets) >= _MaxBuckets {
+		l.evictLocked(now())
+	}
+	b := newBucket(rate)
+	l.buckets[key] = b
+	return b
+}
+
+// evictLocked 删除已回满的令牌桶，与新建的令牌桶等价；仍超过上限时按最近使用时间删除最久未用的令牌桶，
+// 被删除的key下次获取令牌时使用新的令牌桶
+func (l *Limiter) evictLocked(t time.Time) {
+	for key, b := range l.buckets {
+		if b.full(t) {
+			delete(l.buckets, key)
+		}
+	}
+
+	n := len(l.buckets) - _MaxBuckets*3/4
+	if n <= 0 {
+		return
+	}
+	keys := make([]string, 0, len(l.buckets))
+	for key := range l.buckets {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return l.buckets[a].lastUsed().Compare(l.buckets[b].lastUsed())
+	})
+	for _, key := range keys[:n] {
+		delete(l.buckets, key)
+	}
+}
+
+// bucket 令牌桶
+type bucket struct {
+	mu     sync.Mutex
+	qps    float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate Rate) *bucket {
+	burst := float64(max(rate.Burst, 1))
+	return &bucket{
+		qps:    rate.QPS,
+		burst:  burst,
+		tokens: burst,
+		last:   now(),
+	}
+}
+
+// reserve 预占一个令牌，返回需要等待的时长
+func (b *bucket) reserve(t time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := t.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.qps)
+		b.last = t
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.qps * float64(time.Second))
+}
+
+// full 令牌桶在t时是否已回满
+func (b *bucket) full(t time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.tokens+t.Sub(b.last).Seconds()*b.qps >= b.burst
+}
+
+// lastUsed 最近一次获取令牌的时间
+func (b *bucket) lastUsed() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.last
+}
+
+// cancel 归还预占的令牌
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+	b.mu.Unlock()
+}
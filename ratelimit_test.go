@@ -0,0 +1,177 @@
+package volcano
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeNow 替换now为可手动调整的时钟
+func fakeNow(t *testing.T, base time.Time) *time.Time {
+	current := base
+	old := now
+	now = func() time.Time { return current }
+	t.Cleanup(func() { now = old })
+	return &current
+}
+
+func TestLimiterAllow(t *testing.T) {
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		rate  Rate
+		steps []time.Duration // 每次Allow相对base的时间
+		want  []bool
+	}{
+		{name: "burst then throttled", rate: Rate{QPS: 10, Burst: 2}, steps: []time.Duration{0, 0, 0}, want: []bool{true, true, false}},
+		{name: "refilled at qps", rate: Rate{QPS: 10, Burst: 2}, steps: []time.Duration{0, 0, 0, 100 * time.Millisecond, 100 * time.Millisecond}, want: []bool{true, true, false, true, false}},
+		{name: "refill capped at burst", rate: Rate{QPS: 10, Burst: 1}, steps: []time.Duration{0, time.Minute, time.Minute}, want: []bool{true, true, false}},
+		{name: "unlimited", rate: Rate{}, steps: []time.Duration{0, 0, 0}, want: []bool{true, true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := fakeNow(t, base)
+			l := NewLimiter(Rate{}, map[string]Rate{"sami/GetToken": tt.rate}, 0)
+
+			for i, d := range tt.steps {
+				*current = base.Add(d)
+				if got := l.Allow("sami", "GetToken"); got != tt.want[i] {
+					t.Fatalf("Allow() #%d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+			// 其他接口使用Default，不受影响
+			if !l.Allow("sami", "Other") {
+				t.Error("Allow(other) = false")
+			}
+		})
+	}
+
+	var nilLimiter *Limiter
+	if !nilLimiter.Allow("sami", "GetToken") {
+		t.Error("nil Limiter.Allow() = false")
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := NewLimiter(Rate{QPS: 20, Burst: 1}, nil, 0)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		if err := l.Wait(ctx, "iam", "ListUsers"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 第2、3次各等待50ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 calls at 20qps took %v, want >= 100ms", elapsed)
+	}
+
+	// ctx结束时返回ctx的错误并归还令牌
+	l = NewLimiter(Rate{QPS: 1, Burst: 1}, nil, 0)
+	_ = l.Wait(ctx, "iam", "ListUsers")
+	cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(cctx, "iam", "ListUsers"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() = %v, want deadline exceeded", err)
+	}
+	if b := l.buckets["iam/ListUsers"]; b.tokens < -0.5 {
+		t.Errorf("tokens = %v, canceled wait should return its token", b.tokens)
+	}
+}
+
+func TestLimiterAcquireStream(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(Rate{}, nil, 1)
+
+	release, err := l.AcquireStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 会话数达到上限时等待至ctx结束，且不占用会话数
+	for range 2 {
+		cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		_, err = l.AcquireStream(cctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("AcquireStream() = %v, want deadline exceeded", err)
+		}
+	}
+
+	// 重复release只释放一次
+	release()
+	release()
+	release2, err := l.AcquireStream(ctx)
+	if err != nil {
+		t.Fatalf("AcquireStream() after release = %v", err)
+	}
+	cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err = l.AcquireStream(cctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("AcquireStream() = %v, double release freed an extra slot", err)
+	}
+	release2()
+
+	var nilLimiter *Limiter
+	if release, err = nilLimiter.AcquireStream(ctx); err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
+
+func TestLimiterEvictBuckets(t *testing.T) {
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration // 填满后经过的时间
+		want    int           // 清理后最多保留的令牌桶数
+	}{
+		{name: "idle buckets evicted", elapsed: 200 * time.Second, want: 2},
+		{name: "least recently used evicted to bound", want: _MaxBuckets*3/4 + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := fakeNow(t, base)
+			l := NewLimiter(Rate{QPS: 0.01}, nil, 0)
+
+			// 第i个key在base+i ms时使用
+			for i := range _MaxBuckets {
+				*current = base.Add(time.Duration(i) * time.Millisecond)
+				l.Allow("sami-token", strconv.Itoa(i))
+			}
+			if len(l.buckets) != _MaxBuckets {
+				t.Fatalf("buckets = %d, want %d", len(l.buckets), _MaxBuckets)
+			}
+
+			*current = base.Add(_MaxBuckets*time.Millisecond + tt.elapsed)
+			l.Allow("sami-token", "busy")
+			if l.Allow("sami-token", "busy") {
+				t.Fatal("Allow() = true, want throttled")
+			}
+			l.Allow("sami-token", "new")
+
+			if n := len(l.buckets); n > tt.want {
+				t.Errorf("buckets = %d, want at most %d", n, tt.want)
+			}
+			// 清理后新建的令牌桶保留已消耗的令牌
+			if l.Allow("sami-token", "busy") {
+				t.Error("Allow() after eviction = true, want throttled")
+			}
+			if tt.elapsed == 0 {
+				// 最近使用的令牌桶保留，最久未用的被删除
+				if _, ok := l.buckets["sami-token/"+strconv.Itoa(_MaxBuckets-1)]; !ok {
+					t.Error("most recently used bucket evicted")
+				}
+				if _, ok := l.buckets["sami-token/0"]; ok {
+					t.Error("least recently used bucket kept")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package volcano
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy interface {
+	// Backoff 第attempt次（从1开始）调用失败后返回重试前的等待时长，返回false时不再重试
+	Backoff(attempt int, err error) (time.Duration, bool)
+}
+
+// Backoff 带随机抖动的指数退避重试策略
+type Backoff struct {
+	MaxAttempts int              // 最大调用次数（含首次调用），小于等于1时不重试
+	BaseDelay   time.Duration    // 首次重试前的等待时长，之后每次翻倍
+	MaxDelay    time.Duration    // 等待时长上限，为0时不限制
+	Retryable   func(error) bool // 判断错误是否可重试，为空时使用IsRetryable
+}
+
+func (b *Backoff) Backoff(attempt int, err error) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts {
+		return 0, false
+	}
+
+	retryable := b.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	if !retryable(err) {
+		return 0, false
+	}
+
+	delay := b.BaseDelay
+	for i := 1; i < attempt && (b.MaxDelay == 0 || delay < b.MaxDelay); i++ {
+		delay *= 2
+	}
+	if b.MaxDelay > 0 && delay > b.MaxDelay {
+		delay = b.MaxDelay
+	}
+	if delay <= 0 {
+		return 0, true
+	}
+
+	// 在[delay/2, delay]之间随机等待，避免并发请求同时重试
+	return delay/2 + rand.N(delay/2+1), true
+}
+
+var (
+	// DefaultRetryPolicy 默认重试策略：最多调用3次，等待100ms、200ms（含抖动）
+	DefaultRetryPolicy RetryPolicy = &Backoff{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+	// NoRetry 不重试
+	NoRetry RetryPolicy = &Backoff{MaxAttempts: 1}
+)
+
+// Retry 按照policy调用fn直至成功或不再重试，policy为nil时只调用一次；等待期间ctx结束时返回ctx的错误
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || policy == nil {
+			return err
+		}
+
+		delay, ok := policy.Backoff(attempt, err)
+		if !ok {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, context.Cause(ctx))
+		case <-timer.C:
+		}
+	}
+}
+
+// IsRetryable 判断错误是否可重试：网络超时、连接被拒绝、重置或提前关闭、http 429/5xx 以及限流、服务繁忙类错误码
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+
+	// url.Error也实现了net.Error，证书、协议等错误不能重试，只重试超时
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+
+	// 服务端在返回响应前关闭连接时http.Client返回包装io.EOF的url.Error
+	var ue *url.Error
+	if errors.As(err, &ue) && errors.Is(ue.Err, io.EOF) {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,95 @@
+package volcano
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// timeoutError 模拟net/http返回的超时错误
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func urlError(err error) error {
+	return &url.Error{Op: "Post", URL: "https://open.volcengineapi.com", Err: err}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil},
+		{name: "unknown authority", err: urlError(x509.UnknownAuthorityError{})},
+		{name: "hostname mismatch", err: urlError(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "example.com"})},
+		{name: "unsupported scheme", err: &url.Error{Op: "Post", URL: "ftp://example.com", Err: errors.New(`unsupported protocol scheme "ftp"`)}},
+		{name: "dns not found", err: urlError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}})},
+		{name: "timeout", err: urlError(timeoutError{}), want: true},
+		{name: "connection refused", err: urlError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), want: true},
+		{name: "connection reset", err: urlError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), want: true},
+		{name: "connection closed", err: urlError(io.EOF), want: true},
+		{name: "unexpected eof", err: fmt.Errorf("read body failed: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "context canceled", err: errors.Join(urlError(timeoutError{}), context.Canceled)},
+		{name: "context deadline", err: urlError(context.DeadlineExceeded)},
+		{name: "429", err: &Error{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "500", err: &Error{StatusCode: http.StatusInternalServerError}, want: true},
+		{name: "503", err: &Error{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "400", err: &Error{StatusCode: http.StatusBadRequest}},
+		{name: "throttled code", err: &Error{StatusCode: http.StatusOK, Code: "FlowLimitExceeded"}, want: true},
+		{name: "retryable code", err: &Error{StatusCode: http.StatusOK, Code: "ServiceUnavailableTemp"}, want: true},
+		{name: "signature mismatch", err: &Error{StatusCode: http.StatusUnauthorized, Code: "SignatureDoesNotMatch"}},
+		{name: "invalid parameter", err: &Error{StatusCode: http.StatusBadRequest, Code: "InvalidParameter.Speaker"}},
+		{name: "plain error", err: errors.New("marshal failed")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := &Backoff{MaxAttempts: 3}
+
+	tests := []struct {
+		name  string
+		errs  []error
+		calls int
+	}{
+		{name: "ok", errs: []error{nil}, calls: 1},
+		{name: "retried", errs: []error{&Error{StatusCode: http.StatusServiceUnavailable}, nil}, calls: 2},
+		{name: "max attempts", errs: []error{urlError(timeoutError{}), urlError(timeoutError{}), urlError(timeoutError{}), nil}, calls: 3},
+		{name: "not retryable", errs: []error{urlError(x509.UnknownAuthorityError{}), nil}, calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Retry(context.Background(), policy, func(context.Context) error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			if calls != tt.calls {
+				t.Errorf("calls = %d, want %d", calls, tt.calls)
+			}
+			if want := tt.errs[calls-1]; err != want {
+				t.Errorf("Retry() = %v, want %v", err, want)
+			}
+		})
+	}
+}
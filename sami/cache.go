@@ -0,0 +1,326 @@
+package sami
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// 锁的最长持有时间，超过后视为持有方已退出；需大于单次刷新的超时时间
+	_LockStale = time.Minute
+	// 等待锁的轮询间隔
+	_LockPoll = 50 * time.Millisecond
+)
+
+// ErrCacheMiss 缓存中不存在token
+var ErrCacheMiss = errors.New("sami: token cache miss")
+
+// CachedToken 缓存的token
+type CachedToken struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// ValidFor token在d之后是否仍然有效
+func (t CachedToken) ValidFor(d time.Duration) bool {
+	return t.Token != "" && time.Now().Add(d).Before(time.Unix(t.ExpiresAt, 0))
+}
+
+// TokenCache token的共享缓存，多个进程共享时可避免各自调用GetToken
+type TokenCache interface {
+	// Get 不存在时返回ErrCacheMiss
+	Get(ctx context.Context, key string) (CachedToken, error)
+	Set(ctx context.Context, key string, t CachedToken) error
+}
+
+// TokenLocker TokenCache可选实现的锁，刷新期间持有，避免多个进程同时调用GetToken
+type TokenLocker interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// TokenCacheKey 按AppKey、access key和请求的有效期生成缓存的key，key中不包含明文的access key；
+// 有效期不同的token分开缓存，避免取到比请求更长有效期的token
+func TokenCacheKey(appKey, accessKey string, expiration int64) string {
+	sum := sha256.Sum256([]byte(appKey + "\x00" + accessKey + "\x00" + strconv.FormatInt(expiration, 10)))
+	return hex.EncodeToString(sum[:])
+}
+
+// lockToken 生成锁持有方的唯一标识，释放锁时只删除自己持有的锁
+func lockToken() []byte {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return []byte(hex.EncodeToString(b))
+}
+
+// MemoryTokenCache 进程内的缓存，可在多个Token间共享
+type MemoryTokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]CachedToken
+	locks  map[string]chan struct{}
+}
+
+func NewMemoryTokenCache() *MemoryTokenCache {
+	return &MemoryTokenCache{
+		tokens: make(map[string]CachedToken),
+		locks:  make(map[string]chan struct{}),
+	}
+}
+
+func (c *MemoryTokenCache) Get(_ context.Context, key string) (CachedToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.tokens[key]
+	if !ok {
+		return CachedToken{}, ErrCacheMiss
+	}
+	return t, nil
+}
+
+func (c *MemoryTokenCache) Set(_ context.Context, key string, t CachedToken) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens[key] = t
+	return nil
+}
+
+func (c *MemoryTokenCache) Lock(ctx context.Context, key string) (func(), error) {
+	for {
+		c.mu.Lock()
+		held, ok := c.locks[key]
+		if !ok {
+			done := make(chan struct{})
+			c.locks[key] = done
+			c.mu.Unlock()
+
+			return func() {
+				c.mu.Lock()
+				defer c.mu.Unlock()
+
+				delete(c.locks, key)
+				close(done)
+			}, nil
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-held:
+		}
+	}
+}
+
+// FileTokenCache 基于文件的缓存，可在同一台机器或共享存储上的多个进程间共享；
+// 每个key保存为Dir下的一个文件，使用lock文件实现跨进程的锁
+type FileTokenCache struct {
+	Dir string
+}
+
+func NewFileTokenCache(dir string) *FileTokenCache {
+	return &FileTokenCache{Dir: dir}
+}
+
+func (c *FileTokenCache) Get(_ context.Context, key string) (CachedToken, error) {
+	b, err := os.ReadFile(c.filename(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return CachedToken{}, ErrCacheMiss
+	}
+	if err != nil {
+		return CachedToken{}, err
+	}
+
+	var t CachedToken
+	if err = json.Unmarshal(b, &t); err != nil {
+		return CachedToken{}, fmt.Errorf("decode cached token failed: %w", err)
+	}
+	return t, nil
+}
+
+// Set 写入临时文件后重命名，避免其他进程读到不完整的内容
+func (c *FileTokenCache) Set(_ context.Context, key string, t CachedToken) error {
+	b, _ := json.Marshal(t)
+
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.Dir, filepath.Base(c.filename(key))+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.filename(key))
+}
+
+// Lock 独占创建lock文件并写入持有方的标识，释放时只删除内容一致的lock文件；
+// 超过_LockStale未释放的lock文件视为持有方已退出
+func (c *FileTokenCache) Lock(ctx context.Context, key string) (func(), error) {
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	name := c.filename(key) + ".lock"
+	token := lockToken()
+
+	for {
+		err := createLock(name, token)
+		if err == nil {
+			return func() {
+				if b, err := os.ReadFile(name); err == nil && bytes.Equal(b, token) {
+					_ = os.Remove(name)
+				}
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if fi, err := os.Stat(name); err == nil && time.Since(fi.ModTime()) > _LockStale {
+			if err = removeStaleLock(name, fi, token); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-time.After(_LockPoll):
+		}
+	}
+}
+
+// createLock 独占创建lock文件并写入token，写入失败时删除
+func createLock(name string, token []byte) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(token)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(name)
+	}
+	return err
+}
+
+// removeStaleLock 将过期的lock文件重命名为自己独有的名字后删除，多个进程同时抢占时只有一个能重命名成功；
+// 重命名后发现不是stale指向的文件时，说明其他进程已抢占并重新加锁，将其放回原处
+func removeStaleLock(name string, stale os.FileInfo, token []byte) error {
+	tmp := name + ".stale-" + string(token)
+	if err := os.Rename(name, tmp); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	fi, err := os.Stat(tmp)
+	if err == nil && !os.SameFile(fi, stale) {
+		// 期间又有进程加锁时放回失败，最多导致重复调用GetToken
+		_ = os.Link(tmp, name)
+	}
+	return os.Remove(tmp)
+}
+
+func (c *FileTokenCache) filename(key string) string {
+	return filepath.Join(c.Dir, "sami-token-"+key+".json")
+}
+
+// KVStore 外部KV存储的适配接口，如redis、memcached
+type KVStore interface {
+	// Get 不存在时返回ErrCacheMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set ttl为key的有效期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// KVLocker KVStore可选实现的原子操作，用于实现跨进程的锁
+type KVLocker interface {
+	// SetNX key不存在时写入并返回true
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// CompareAndDelete key的值与value一致时删除并返回true，如redis中使用lua脚本实现
+	CompareAndDelete(ctx context.Context, key string, value []byte) (bool, error)
+}
+
+// KVTokenCache 使用外部KV存储的缓存，有效期与token的ExpiresAt一致
+type KVTokenCache struct {
+	Store KVStore
+	// Prefix key的前缀
+	Prefix string
+}
+
+func NewKVTokenCache(store KVStore, prefix string) *KVTokenCache {
+	return &KVTokenCache{Store: store, Prefix: prefix}
+}
+
+func (c *KVTokenCache) Get(ctx context.Context, key string) (CachedToken, error) {
+	b, err := c.Store.Get(ctx, c.Prefix+key)
+	if err != nil {
+		return CachedToken{}, err
+	}
+
+	var t CachedToken
+	if err = json.Unmarshal(b, &t); err != nil {
+		return CachedToken{}, fmt.Errorf("decode cached token failed: %w", err)
+	}
+	return t, nil
+}
+
+func (c *KVTokenCache) Set(ctx context.Context, key string, t CachedToken) error {
+	ttl := time.Until(time.Unix(t.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+
+	b, _ := json.Marshal(t)
+	return c.Store.Set(ctx, c.Prefix+key, b, ttl)
+}
+
+// Lock 写入持有方的唯一标识，释放时只删除自己持有的锁；Store未实现KVLocker时不加锁
+func (c *KVTokenCache) Lock(ctx context.Context, key string) (func(), error) {
+	locker, ok := c.Store.(KVLocker)
+	if !ok {
+		return func() {}, nil
+	}
+
+	name := c.Prefix + key + ".lock"
+	token := lockToken()
+	for {
+		ok, err := locker.SetNX(ctx, name, token, _LockStale)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return func() { _, _ = locker.CompareAndDelete(context.WithoutCancel(ctx), name, token) }, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-time.After(_LockPoll):
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package sami
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/jyinz/volcano-sdk/volcanotest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryKV 实现KVStore和KVLocker，忽略ttl
+type memoryKV struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newMemoryKV() *memoryKV {
+	return &memoryKV{values: make(map[string][]byte)}
+}
+
+func (s *memoryKV) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.values[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	return v, nil
+}
+
+func (s *memoryKV) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = value
+	return nil
+}
+
+func (s *memoryKV) SetNX(_ context.Context, key string, value []byte, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.values[key]; ok {
+		return false, nil
+	}
+	s.values[key] = value
+	return true, nil
+}
+
+func (s *memoryKV) CompareAndDelete(_ context.Context, key string, value []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !bytes.Equal(s.values[key], value) {
+		return false, nil
+	}
+	delete(s.values, key)
+	return true, nil
+}
+
+func (s *memoryKV) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, key)
+}
+
+type lockingCache interface {
+	TokenCache
+	TokenLocker
+}
+
+func TestTokenCache(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache func(t *testing.T) lockingCache
+	}{
+		{name: "memory", cache: func(t *testing.T) lockingCache { return NewMemoryTokenCache() }},
+		{name: "file", cache: func(t *testing.T) lockingCache { return NewFileTokenCache(t.TempDir()) }},
+		{name: "kv", cache: func(t *testing.T) lockingCache { return NewKVTokenCache(newMemoryKV(), "sami:") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := tt.cache(t)
+
+			if _, err := c.Get(ctx, "key"); !errors.Is(err, ErrCacheMiss) {
+				t.Fatalf("Get() = %v, want ErrCacheMiss", err)
+			}
+			want := CachedToken{Token: "token", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+			if err := c.Set(ctx, "key", want); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := c.Get(ctx, "key"); err != nil || got != want {
+				t.Fatalf("Get() = %v, %v, want %v", got, err, want)
+			}
+
+			unlock, err := c.Lock(ctx, "key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 持有期间其他调用方等待到ctx结束
+			wctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			if _, err = c.Lock(wctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Lock() = %v, want deadline exceeded", err)
+			}
+			if other, err := c.Lock(ctx, "other"); err != nil {
+				t.Fatalf("Lock(other) = %v", err)
+			} else {
+				other()
+			}
+
+			unlock()
+			unlock2, err := c.Lock(ctx, "key")
+			if err != nil {
+				t.Fatalf("Lock() after unlock = %v", err)
+			}
+			unlock2()
+		})
+	}
+}
+
+func TestTokenCacheStaleLock(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache func(t *testing.T) (lockingCache, func(key string))
+	}{
+		{
+			name: "file",
+			cache: func(t *testing.T) (lockingCache, func(key string)) {
+				c := NewFileTokenCache(t.TempDir())
+				return c, func(key string) {
+					old := time.Now().Add(-2 * _LockStale)
+					if err := os.Chtimes(c.filename(key)+".lock", old, old); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+		},
+		{
+			name: "kv",
+			cache: func(t *testing.T) (lockingCache, func(key string)) {
+				kv := newMemoryKV()
+				c := NewKVTokenCache(kv, "sami:")
+				return c, func(key string) { kv.delete("sami:" + key + ".lock") }
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c, expire := tt.cache(t)
+
+			unlock1, err := c.Lock(ctx, "key")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 第一个持有方超时后被抢占
+			expire("key")
+			unlock2, err := c.Lock(ctx, "key")
+			if err != nil {
+				t.Fatalf("Lock() stale = %v", err)
+			}
+
+			// 第一个持有方释放时不能删除第二个持有方的锁
+			unlock1()
+			wctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			if _, err = c.Lock(wctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Lock() after stale unlock = %v, want deadline exceeded", err)
+			}
+
+			unlock2()
+			unlock3, err := c.Lock(ctx, "key")
+			if err != nil {
+				t.Fatalf("Lock() after unlock = %v", err)
+			}
+			unlock3()
+		})
+	}
+}
+
+func TestTokenCacheExpiration(t *testing.T) {
+	s := volcanotest.NewServer()
+	defer s.Close()
+
+	var expirations []int64
+	s.Handle("GetToken", _Version, func(r *volcanotest.Request) *volcanotest.Response {
+		var gr GetTokenRequest
+		_ = r.Decode(&gr)
+		expirations = append(expirations, gr.Expiration)
+		return &volcanotest.Response{Result: GetTokenResponse{
+			StatusCode: _ResponseOK,
+			Token:      "token",
+			ExpiresAt:  time.Now().Unix() + gr.Expiration,
+		}}
+	})
+
+	cache := NewMemoryTokenCache()
+	tests := []struct {
+		name       string
+		expiration int64
+		calls      int
+	}{
+		{name: "long", expiration: 12 * 3600, calls: 1},
+		{name: "short not served from long", expiration: 3600, calls: 2},
+		{name: "short cached", expiration: 3600, calls: 2},
+		{name: "long cached", expiration: 12 * 3600, calls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tkn := NewOpenApi(s.Config())
+			tkn.Cache = cache
+
+			got, err := tkn.fetch(context.Background(), "appkey", tt.expiration, _ExpirySafety, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(expirations) != tt.calls {
+				t.Errorf("GetToken called %d times, want %d", len(expirations), tt.calls)
+			}
+			if limit := time.Now().Unix() + tt.expiration; got.ExpiresAt > limit {
+				t.Errorf("token expires at %d, later than requested %d", got.ExpiresAt, limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package sami
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jyinz/volcano-sdk"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// _InvokePath 非流式任务的http地址
+const _InvokePath = "/api/v1/invoke"
+
+// InvokeRequest 非流式任务请求，如离线音色转换、音频分离、语音增强
+type InvokeRequest struct {
+	Namespace string
+	Version   string
+	// Payload 任务参数，string、[]byte原样发送，其他类型序列化为json
+	Payload any
+	// Data 待处理的音频等二进制数据，以base64编码发送
+	Data []byte
+}
+
+// invokeBody 非流式任务的请求体，token、appkey等通过query传递
+type invokeBody struct {
+	Payload string `json:"payload,omitempty"`
+	Data    []byte `json:"data,omitempty"`
+}
+
+// Invoke 以http调用非流式任务，返回包的Data为解码后的二进制数据；按Retry重试可重试的错误
+func (c *Client) Invoke(ctx context.Context, ir InvokeRequest) (*WebSocketResponse, error) {
+	payload, err := encodePayload(ir.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload failed: %w", err)
+	}
+
+	var rsp *WebSocketResponse
+	err = volcano.Retry(ctx, c.Retry, func(ctx context.Context) (err error) {
+		rsp, err = c.invokeWithToken(ctx, ir, payload)
+		return err
+	})
+	return rsp, err
+}
+
+// invokeWithToken 获取token后调用任务，token被服务端判定无效时丢弃缓存的token，重新获取后重试一次
+func (c *Client) invokeWithToken(ctx context.Context, ir InvokeRequest, payload string) (*WebSocketResponse, error) {
+	for retried := false; ; retried = true {
+		token, err := c.Tokens.Token(ctx, c.AppKey)
+		if err != nil {
+			return nil, fmt.Errorf("get token failed: %w", err)
+		}
+
+		rsp, err := c.invoke(ctx, ir, payload, token)
+		if retried || !isInvalidToken(err) {
+			return rsp, err
+		}
+		c.Tokens.Invalidate(c.AppKey, token)
+	}
+}
+
+func (c *Client) invoke(ctx context.Context, ir InvokeRequest, payload, token string) (_ *WebSocketResponse, err error) {
+	err = c.Limiter.Wait(ctx, _Service, ir.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := volcano.StartSpan(ctx, c.Instrumenter, volcano.Operation{Kind: volcano.KindHTTP, Service: _Service, Action: ir.Namespace})
+	var status int
+	defer func() { span.End(status, err) }()
+
+	b, _ := json.Marshal(invokeBody{Payload: payload, Data: ir.Data})
+	span.AddBytes(len(b), 0)
+
+	u := httpEndpoint(c.Endpoint.Resolve(_Endpoint)).URL(_InvokePath)
+	query := url.Values{
+		"appkey":    []string{c.AppKey},
+		"namespace": []string{ir.Namespace},
+	}
+	if ir.Version != "" {
+		query.Set("version", ir.Version)
+	}
+	// 日志中不输出token
+	logged := u
+	logged.RawQuery = query.Encode()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	log := volcano.NewLogger(c.Logger).With("service", _Service, "action", ir.Namespace)
+	log.DebugContext(ctx, "volcano sami request", "url", logged.String(), "data_size", len(ir.Data))
+
+	var (
+		start  = time.Now()
+		taskId string
+	)
+	defer func() {
+		log.DebugContext(ctx, "volcano sami response", "status", status, "task_id", taskId, "elapsed", time.Since(start), "error", err)
+	}()
+
+	hrsp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request failed: %w", err)
+	}
+	status = hrsp.StatusCode
+	span.FirstChunk()
+
+	defer hrsp.Body.Close()
+
+	rb, err := io.ReadAll(hrsp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	span.AddBytes(0, len(rb))
+
+	// 请求失败，解析错误原因
+	if hrsp.StatusCode != http.StatusOK {
+		return nil, &volcano.Error{
+			StatusCode: hrsp.StatusCode,
+			Service:    _Service,
+			Action:     ir.Namespace,
+			Message:    string(rb),
+		}
+	}
+
+	rsp := new(WebSocketResponse)
+	if err = json.Unmarshal(rb, rsp); err != nil {
+		return nil, fmt.Errorf("parse data failed: %w", err)
+	}
+	taskId = rsp.TaskId
+	if rsp.Namespace == "" {
+		rsp.Namespace = ir.Namespace
+	}
+	if rsp.StatusCode != 0 {
+		status = int(rsp.StatusCode)
+	}
+
+	if err = rsp.Err(); err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// httpEndpoint 将websocket地址转换为对应的http地址
+func httpEndpoint(e volcano.Endpoint) volcano.Endpoint {
+	switch e.Scheme {
+	case "wss":
+		e.Scheme = "https"
+	case "ws":
+		e.Scheme = "http"
+	}
+	return e
+}
+
+// isInvalidToken 服务端返回token无效
+func isInvalidToken(err error) bool {
+	var e *volcano.Error
+	return errors.As(err, &e) && e.Service == _Service && e.Code == strconv.Itoa(_ResponseInvalidToken)
+}
@@ -0,0 +1,264 @@
+package sami
+
+import (
+	"context"
+	"errors"
+	"github.com/jyinz/volcano-sdk"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultExpiration TokenManager默认申请的token有效期，单位秒
+	DefaultExpiration = 12 * 3600
+	// DefaultRefreshBefore TokenManager默认在过期前5min后台刷新
+	DefaultRefreshBefore = 5 * time.Minute
+
+	// 提前1min判断过期，保证token使用过程时有效
+	_ExpirySafety = time.Minute
+	// 后台刷新失败后重试的间隔
+	_RetryInterval = 10 * time.Second
+	// 单次刷新的超时时间，刷新由多个调用方共享，不受发起方ctx取消的影响
+	_RefreshTimeout = 30 * time.Second
+)
+
+// ErrManagerClosed TokenManager已关闭
+var ErrManagerClosed = errors.New("sami: token manager closed")
+
+// TokenManager 并发安全的token管理，按AppKey缓存token：
+// 并发的刷新请求会合并为一次GetToken，刷新失败的错误会返回给所有等待方；
+// 获取过的AppKey会在过期前RefreshBefore在后台主动刷新，直至Close；可在多个SAMI客户端间共享
+type TokenManager struct {
+	// Expiration 申请的token有效期，单位秒，为0时使用DefaultExpiration
+	Expiration int64
+	// RefreshBefore 过期前多久在后台刷新，为0时使用DefaultRefreshBefore，超过有效期一半时按一半计算
+	RefreshBefore time.Duration
+
+	api *Token
+
+	mu      sync.Mutex
+	entries map[string]*tokenEntry
+	closed  bool
+}
+
+type tokenEntry struct {
+	token     string
+	expiresAt time.Time
+	err       error  // 最近一次刷新失败的原因
+	rejected  string // 被服务端判定无效的token，刷新时不从共享缓存中读取
+
+	call  *refreshCall
+	timer *time.Timer
+}
+
+// refreshCall 进行中的刷新，done关闭后err为刷新结果
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// NewTokenManager 使用api调用GetToken生成TokenManager
+func NewTokenManager(api *Token) *TokenManager {
+	return &TokenManager{
+		api:     api,
+		entries: make(map[string]*tokenEntry),
+	}
+}
+
+// Token 获取appKey当前有效的token，token不存在或即将过期时刷新
+func (m *TokenManager) Token(ctx context.Context, appKey string) (string, error) {
+	t, err := m.Get(ctx, appKey)
+	return t.Token, err
+}
+
+// Get 同Token，同时返回token的过期时间
+func (m *TokenManager) Get(ctx context.Context, appKey string) (CachedToken, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return CachedToken{}, ErrManagerClosed
+	}
+	e := m.entry(appKey)
+	if e.valid() {
+		t := e.cached()
+		m.mu.Unlock()
+		return t, nil
+	}
+	call := m.refreshLocked(appKey, e)
+	m.mu.Unlock()
+
+	return m.wait(ctx, appKey, call)
+}
+
+// Refresh 强制刷新appKey的token，与进行中的刷新合并
+func (m *TokenManager) Refresh(ctx context.Context, appKey string) (string, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return "", ErrManagerClosed
+	}
+	call := m.refreshLocked(appKey, m.entry(appKey))
+	m.mu.Unlock()
+
+	t, err := m.wait(ctx, appKey, call)
+	return t.Token, err
+}
+
+// Set 设置appKey的token，一般用于测试或从外部获取的token
+func (m *TokenManager) Set(appKey, token string, expiresAt int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.entry(appKey)
+	e.token, e.expiresAt, e.err = token, time.Unix(expiresAt, 0), nil
+	m.scheduleLocked(appKey, e)
+}
+
+// Invalidate 丢弃appKey缓存的token，用于服务端判定token无效时；
+// token已被刷新为其他值时不做处理，之后获取token将重新调用GetToken
+func (m *TokenManager) Invalidate(appKey, token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[appKey]
+	if !ok || e.token != token {
+		return
+	}
+	e.token, e.expiresAt, e.rejected = "", time.Time{}, token
+	m.scheduleLocked(appKey, e)
+}
+
+// Err 返回appKey最近一次刷新失败的原因，刷新成功后为nil
+func (m *TokenManager) Err(appKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[appKey]; ok {
+		return e.err
+	}
+	return nil
+}
+
+// Close 停止后台刷新，之后获取token将返回ErrManagerClosed
+func (m *TokenManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	for _, e := range m.entries {
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+	}
+}
+
+func (m *TokenManager) entry(appKey string) *tokenEntry {
+	e, ok := m.entries[appKey]
+	if !ok {
+		e = new(tokenEntry)
+		m.entries[appKey] = e
+	}
+	return e
+}
+
+func (e *tokenEntry) valid() bool {
+	return e.token != "" && time.Now().Before(e.expiresAt.Add(-_ExpirySafety))
+}
+
+func (e *tokenEntry) cached() CachedToken {
+	return CachedToken{Token: e.token, ExpiresAt: e.expiresAt.Unix()}
+}
+
+func (m *TokenManager) wait(ctx context.Context, appKey string, call *refreshCall) (CachedToken, error) {
+	select {
+	case <-ctx.Done():
+		return CachedToken{}, context.Cause(ctx)
+	case <-call.done:
+	}
+	if call.err != nil {
+		return CachedToken{}, call.err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.entry(appKey).cached(), nil
+}
+
+// refreshLocked 发起刷新，已有进行中的刷新时直接返回
+func (m *TokenManager) refreshLocked(appKey string, e *tokenEntry) *refreshCall {
+	if e.call != nil {
+		return e.call
+	}
+
+	call := &refreshCall{done: make(chan struct{})}
+	e.call = call
+	rejected := e.rejected
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), _RefreshTimeout)
+		defer cancel()
+
+		expiration := m.Expiration
+		if expiration <= 0 {
+			expiration = DefaultExpiration
+		}
+		before := m.RefreshBefore
+		if before <= 0 {
+			before = DefaultRefreshBefore
+		}
+		// 缓存中的token需在下一次后台刷新前仍然有效
+		t, err := m.api.fetch(ctx, appKey, expiration, before+_ExpirySafety, rejected)
+		if err != nil {
+			volcano.NewLogger(m.api.Logger).WarnContext(ctx, "volcano sami token refresh failed", "appkey", appKey, "error", err)
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if err != nil {
+			e.err = err
+		} else {
+			e.token, e.expiresAt, e.err, e.rejected = t.Token, time.Unix(t.ExpiresAt, 0), nil, ""
+		}
+		e.call = nil
+		m.scheduleLocked(appKey, e)
+
+		call.err = err
+		close(call.done)
+	}()
+
+	return call
+}
+
+// scheduleLocked 安排下一次后台刷新：token有效时在过期前RefreshBefore刷新，刷新失败时间隔_RetryInterval重试
+func (m *TokenManager) scheduleLocked(appKey string, e *tokenEntry) {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	if m.closed || !e.valid() {
+		return
+	}
+
+	lifetime := time.Until(e.expiresAt.Add(-_ExpirySafety))
+	before := m.RefreshBefore
+	if before <= 0 {
+		before = DefaultRefreshBefore
+	}
+	before = min(before, lifetime/2)
+
+	d := lifetime - before
+	if e.err != nil {
+		d = min(d, _RetryInterval)
+	}
+
+	e.timer = time.AfterFunc(d, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if !m.closed {
+			m.refreshLocked(appKey, e)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package sami
+
+import (
+	"context"
+	"errors"
+	"github.com/jyinz/volcano-sdk/volcanotest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTokenServer 模拟GetToken，第n次调用返回token-n，有效期为lifetime
+func newTokenServer(lifetime time.Duration, delay time.Duration) (*volcanotest.Server, *atomic.Int32) {
+	s := volcanotest.NewServer()
+	calls := new(atomic.Int32)
+	s.Handle("GetToken", _Version, func(r *volcanotest.Request) *volcanotest.Response {
+		n := calls.Add(1)
+		return &volcanotest.Response{
+			Result: GetTokenResponse{
+				StatusCode: _ResponseOK,
+				Token:      "token-" + strconv.Itoa(int(n)),
+				ExpiresAt:  time.Now().Add(lifetime).Unix(),
+			},
+			Delay: delay,
+		}
+	})
+	return s, calls
+}
+
+func TestTokenManagerSingleFlight(t *testing.T) {
+	s, calls := newTokenServer(time.Hour, 50*time.Millisecond)
+	defer s.Close()
+
+	m := NewTokenManager(NewOpenApi(s.Config()))
+	defer m.Close()
+
+	var (
+		wg     sync.WaitGroup
+		tokens = make([]string, 20)
+		errs   = make([]error, 20)
+	)
+	for i := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens[i], errs[i] = m.Token(context.Background(), "appkey")
+		}()
+	}
+	wg.Wait()
+
+	for i := range tokens {
+		if errs[i] != nil || tokens[i] != "token-1" {
+			t.Fatalf("Token() #%d = %q, %v, want token-1", i, tokens[i], errs[i])
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("GetToken called %d times, want 1", n)
+	}
+
+	// 有效期内直接返回缓存的token
+	if tkn, err := m.Token(context.Background(), "appkey"); err != nil || tkn != "token-1" {
+		t.Errorf("Token() = %q, %v", tkn, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("GetToken called %d times after cached Token(), want 1", n)
+	}
+}
+
+func TestTokenManagerRefreshError(t *testing.T) {
+	s := volcanotest.NewServer()
+	defer s.Close()
+	s.Respond("GetToken", _Version, GetTokenResponse{StatusCode: 45000001, StatusText: "invalid appkey"})
+
+	m := NewTokenManager(NewOpenApi(s.Config()))
+	defer m.Close()
+
+	// 刷新失败的错误返回给所有等待方，并可通过Err获取
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Token(context.Background(), "appkey"); err == nil {
+				t.Error("Token() = nil, want error")
+			}
+		}()
+	}
+	wg.Wait()
+	if m.Err("appkey") == nil {
+		t.Error("Err() = nil after failed refresh")
+	}
+}
+
+func TestTokenManagerBackgroundRefresh(t *testing.T) {
+	// 扣除_ExpirySafety后有效期约2s，在剩余一半时后台刷新
+	s, calls := newTokenServer(_ExpirySafety+2*time.Second, 0)
+	defer s.Close()
+
+	m := NewTokenManager(NewOpenApi(s.Config()))
+	defer m.Close()
+
+	first, err := m.Get(context.Background(), "appkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("token not refreshed in background")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// 后台刷新发生在过期前，调用方无需等待GetToken
+	got, err := m.Get(context.Background(), "appkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Token != "token-2" {
+		t.Errorf("Get() = %q, want refreshed token-2", got.Token)
+	}
+	if refreshed := time.Now(); !refreshed.Before(time.Unix(first.ExpiresAt, 0).Add(-_ExpirySafety)) {
+		t.Errorf("refreshed at %v, after token expiry %v", refreshed, time.Unix(first.ExpiresAt, 0))
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("GetToken called %d times, want 2", n)
+	}
+}
+
+func TestTokenManagerClose(t *testing.T) {
+	s, calls := newTokenServer(_ExpirySafety+2*time.Second, 0)
+	defer s.Close()
+
+	m := NewTokenManager(NewOpenApi(s.Config()))
+	if _, err := m.Get(context.Background(), "appkey"); err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+
+	// Close后不再后台刷新
+	time.Sleep(2 * time.Second)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("GetToken called %d times after Close, want 1", n)
+	}
+
+	if _, err := m.Get(context.Background(), "appkey"); !errors.Is(err, ErrManagerClosed) {
+		t.Errorf("Get() = %v, want ErrManagerClosed", err)
+	}
+	if _, err := m.Refresh(context.Background(), "appkey"); !errors.Is(err, ErrManagerClosed) {
+		t.Errorf("Refresh() = %v, want ErrManagerClosed", err)
+	}
+}
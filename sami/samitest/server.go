@@ -0,0 +1,401 @@
+// Package samitest 提供本地的SAMI模拟服务，实现 /api/v1/ws 的任务事件流程和 /api/v1/invoke 的非流式任务，并通过 volcanotest 模拟GetToken
+//
+//	srv := samitest.NewServer()
+//	defer srv.Close()
+//
+//	srv.Script(samitest.Session{FailAfter: 3})
+//	c := vc.New(srv.Config())
+//	defer c.Close()
+//	err := c.Conversion(ctx, vcr, audio, cb)
+package samitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/sami"
+	"github.com/jyinz/volcano-sdk/sami/vc"
+	"github.com/jyinz/volcano-sdk/volcanotest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// AppKey 模拟服务默认接受的appkey
+	AppKey = "samitest-appkey"
+	// Token GetToken返回、会话校验的token
+	Token = "samitest-token"
+
+	_Path       = "/api/v1/ws"
+	_InvokePath = "/api/v1/invoke"
+	_Version    = "2021-07-27"
+)
+
+// 模拟服务使用的状态码
+const (
+	StatusOK             int32 = 20000000
+	StatusInvalidRequest int32 = 45000001
+	StatusInvalidToken   int32 = 45000002
+	StatusInternalError  int32 = 55000000
+)
+
+// Failure 模拟服务返回的TaskFailed事件
+type Failure struct {
+	StatusCode int32
+	StatusText string
+}
+
+// Session 一次会话的脚本，零值表示正常完成会话并原样返回音频；
+// Invoke请求使用StartDelay、StartFailure和Transform
+type Session struct {
+	// StartDelay 收到StartTask后等待的时间
+	StartDelay time.Duration
+	// StartFailure 不为空时StartTask返回TaskFailed
+	StartFailure *Failure
+
+	// FrameDelay 每个音频帧返回前等待的时间
+	FrameDelay time.Duration
+	// Transform 转换音频帧，为nil时原样返回
+	Transform func([]byte) []byte
+	// TaskResponse 为true时音频通过TaskResponse事件的data返回，否则以二进制帧返回
+	TaskResponse bool
+
+	// FailAfter 大于0时在收到第FailAfter个音频帧后返回Failure
+	FailAfter int
+	Failure   *Failure
+	// DropAfter 大于0时在收到第DropAfter个音频帧后直接断开连接
+	DropAfter int
+}
+
+// HandlerFunc 根据StartTask或Invoke请求返回会话脚本
+type HandlerFunc func(req *sami.WebSocketRequest) Session
+
+// Record 模拟服务记录的会话
+type Record struct {
+	TaskId   string
+	Start    sami.WebSocketRequest
+	Frames   int  // 收到的音频帧数
+	Bytes    int  // 收到的音频字节数
+	Finished bool // 是否收到FinishTask
+}
+
+// Server 模拟的SAMI服务
+type Server struct {
+	*httptest.Server
+
+	// OpenApi 模拟GetToken的OpenApi服务
+	OpenApi *volcanotest.Server
+
+	// AppKey、Token 会话校验的appkey和token，为空时不校验
+	AppKey string
+	Token  string
+
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	handler  HandlerFunc
+	sessions []*Record
+
+	seq atomic.Int64
+}
+
+// NewServer 启动模拟服务和GetToken使用的OpenApi服务
+func NewServer() *Server {
+	s := &Server{
+		OpenApi: volcanotest.NewServer(),
+		AppKey:  AppKey,
+		Token:   Token,
+	}
+	s.OpenApi.Handle("GetToken", _Version, s.getToken)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close 关闭模拟服务
+func (s *Server) Close() {
+	s.Server.Close()
+	s.OpenApi.Close()
+}
+
+// Handle 设置会话脚本的处理函数，为nil时所有会话正常完成
+func (s *Server) Handle(h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handler = h
+}
+
+// Script 所有会话使用相同的脚本
+func (s *Server) Script(session Session) {
+	s.Handle(func(*sami.WebSocketRequest) Session {
+		return session
+	})
+}
+
+// Sessions 返回所有会话的记录
+func (s *Server) Sessions() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]Record, len(s.sessions))
+	for i, r := range s.sessions {
+		ret[i] = *r
+	}
+	return ret
+}
+
+// Endpoint 模拟服务的websocket地址
+func (s *Server) Endpoint() volcano.Endpoint {
+	u, _ := url.Parse(s.URL)
+	return volcano.Endpoint{Host: u.Host, Scheme: "ws"}
+}
+
+// Config 访问模拟服务的音色转换配置
+func (s *Server) Config() vc.Config {
+	appKey := s.AppKey
+	if appKey == "" {
+		appKey = AppKey
+	}
+	return vc.Config{
+		Config:       s.OpenApi.Config(),
+		AppKey:       appKey,
+		SamiEndpoint: s.Endpoint(),
+	}
+}
+
+func (s *Server) getToken(r *volcanotest.Request) *volcanotest.Response {
+	var gr sami.GetTokenRequest
+	if err := r.Decode(&gr); err != nil {
+		return &volcanotest.Response{Result: sami.GetTokenResponse{StatusCode: StatusInvalidRequest, StatusText: err.Error()}}
+	}
+	if s.AppKey != "" && gr.AppKey != s.AppKey {
+		return &volcanotest.Response{Result: sami.GetTokenResponse{StatusCode: StatusInvalidRequest, StatusText: "invalid appkey"}}
+	}
+
+	token := s.Token
+	if token == "" {
+		token = Token
+	}
+	return &volcanotest.Response{Result: sami.GetTokenResponse{
+		StatusCode: StatusOK,
+		StatusText: "OK",
+		TaskId:     s.taskId(),
+		Token:      token,
+		ExpiresAt:  time.Now().Unix() + gr.Expiration,
+	}}
+}
+
+func (s *Server) taskId() string {
+	return fmt.Sprintf("samitest-%d", s.seq.Add(1))
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == _InvokePath {
+		s.serveInvoke(w, r)
+		return
+	}
+	if r.URL.Path != _Path {
+		http.NotFound(w, r)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var start sami.WebSocketRequest
+	if err = conn.ReadJSON(&start); err != nil {
+		return
+	}
+
+	rec := &Record{TaskId: s.taskId(), Start: start}
+	s.mu.Lock()
+	s.sessions = append(s.sessions, rec)
+	h := s.handler
+	s.mu.Unlock()
+
+	var session Session
+	if h != nil {
+		session = h(&start)
+	}
+
+	reply := func(event string, f *Failure, data []byte) error {
+		rsp := sami.WebSocketResponse{
+			TaskId:     rec.TaskId,
+			MessageId:  s.taskId(),
+			Namespace:  start.Namespace,
+			Event:      event,
+			StatusCode: StatusOK,
+			StatusText: "OK",
+			Data:       data,
+		}
+		if f != nil {
+			rsp.StatusCode, rsp.StatusText = f.StatusCode, f.StatusText
+		}
+		return conn.WriteJSON(rsp)
+	}
+
+	if !sleep(r, session.StartDelay) {
+		return
+	}
+
+	switch {
+	case session.StartFailure != nil:
+		_ = reply(sami.EventTaskFailed, session.StartFailure, nil)
+		return
+	case start.Event != sami.EventStartTask:
+		_ = reply(sami.EventTaskFailed, &Failure{StatusInvalidRequest, "expect StartTask, got " + start.Event}, nil)
+		return
+	case s.AppKey != "" && start.Appkey != s.AppKey:
+		_ = reply(sami.EventTaskFailed, &Failure{StatusInvalidRequest, "invalid appkey"}, nil)
+		return
+	case s.Token != "" && start.Token != s.Token:
+		_ = reply(sami.EventTaskFailed, &Failure{StatusInvalidToken, "invalid token"}, nil)
+		return
+	}
+
+	if err = reply(sami.EventTaskStarted, nil, nil); err != nil {
+		return
+	}
+
+	for {
+		mt, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if mt == websocket.TextMessage {
+			var req sami.WebSocketRequest
+			if err = json.Unmarshal(msg, &req); err != nil {
+				_ = reply(sami.EventTaskFailed, &Failure{StatusInvalidRequest, err.Error()}, nil)
+				return
+			}
+			if req.Event == sami.EventFinishTask {
+				s.mu.Lock()
+				rec.Finished = true
+				s.mu.Unlock()
+
+				_ = reply(sami.EventTaskFinished, nil, nil)
+				return
+			}
+			continue
+		}
+
+		s.mu.Lock()
+		rec.Frames++
+		rec.Bytes += len(msg)
+		frames := rec.Frames
+		s.mu.Unlock()
+
+		if session.DropAfter > 0 && frames >= session.DropAfter {
+			// 不发送close帧，模拟连接异常断开
+			_ = conn.NetConn().Close()
+			return
+		}
+		if session.FailAfter > 0 && frames >= session.FailAfter {
+			f := session.Failure
+			if f == nil {
+				f = &Failure{StatusInternalError, "internal error"}
+			}
+			_ = reply(sami.EventTaskFailed, f, nil)
+			return
+		}
+
+		if !sleep(r, session.FrameDelay) {
+			return
+		}
+
+		if session.Transform != nil {
+			msg = session.Transform(msg)
+		}
+		if session.TaskResponse {
+			err = reply(sami.EventTaskResponse, nil, msg)
+		} else {
+			err = conn.WriteMessage(websocket.BinaryMessage, msg)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// serveInvoke 处理非流式任务，appkey、token等从query读取，payload、data从body读取
+func (s *Server) serveInvoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req sami.WebSocketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	req.Appkey, req.Token, req.Namespace, req.Version = q.Get("appkey"), q.Get("token"), q.Get("namespace"), q.Get("version")
+
+	rec := &Record{TaskId: s.taskId(), Start: req, Frames: 1, Bytes: len(req.Data), Finished: true}
+	s.mu.Lock()
+	s.sessions = append(s.sessions, rec)
+	h := s.handler
+	s.mu.Unlock()
+
+	var session Session
+	if h != nil {
+		session = h(&req)
+	}
+
+	rsp := sami.WebSocketResponse{
+		TaskId:     rec.TaskId,
+		MessageId:  s.taskId(),
+		Namespace:  req.Namespace,
+		StatusCode: StatusOK,
+		StatusText: "OK",
+		Payload:    req.Payload,
+	}
+	fail := func(f *Failure) {
+		rsp.Event, rsp.StatusCode, rsp.StatusText, rsp.Payload = sami.EventTaskFailed, f.StatusCode, f.StatusText, ""
+	}
+
+	if !sleep(r, session.StartDelay) {
+		return
+	}
+
+	switch {
+	case session.StartFailure != nil:
+		fail(session.StartFailure)
+	case s.AppKey != "" && req.Appkey != s.AppKey:
+		fail(&Failure{StatusInvalidRequest, "invalid appkey"})
+	case s.Token != "" && req.Token != s.Token:
+		fail(&Failure{StatusInvalidToken, "invalid token"})
+	default:
+		rsp.Data = req.Data
+		if session.Transform != nil {
+			rsp.Data = session.Transform(req.Data)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rsp)
+}
+
+// sleep 等待d，请求结束时返回false
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	select {
+	case <-r.Context().Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -0,0 +1,244 @@
+package samitest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/sami"
+	"github.com/jyinz/volcano-sdk/sami/samitest"
+	"github.com/jyinz/volcano-sdk/sami/vc"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		session  samitest.Session
+		token    string // 不为空时客户端使用该token
+		want     string
+		wantErr  func(err error) bool
+		frames   int
+		finished bool
+	}{
+		{name: "echo", want: "abcdef", frames: 3, finished: true},
+		{name: "transform", session: samitest.Session{Transform: bytes.ToUpper}, want: "ABCDEF", frames: 3, finished: true},
+		{name: "task response", session: samitest.Session{TaskResponse: true}, want: "abcdef", frames: 3, finished: true},
+		{
+			name:    "fail after",
+			session: samitest.Session{FailAfter: 2, Failure: &samitest.Failure{StatusCode: samitest.StatusInternalError, StatusText: "boom"}},
+			want:    "ab",
+			wantErr: func(err error) bool {
+				var e *volcano.Error
+				return errors.As(err, &e) && e.Code == "55000000"
+			},
+			frames: 2,
+		},
+		{
+			name:    "drop after",
+			session: samitest.Session{DropAfter: 2},
+			want:    "ab",
+			wantErr: func(err error) bool { return err != nil },
+			frames:  2,
+		},
+		{
+			name:    "start failure",
+			session: samitest.Session{StartFailure: &samitest.Failure{StatusCode: samitest.StatusInvalidRequest, StatusText: "bad speaker"}},
+			wantErr: func(err error) bool { return err != nil },
+		},
+		{
+			name:  "invalid token",
+			token: "other",
+			wantErr: func(err error) bool {
+				var e *volcano.Error
+				return errors.As(err, &e) && e.Code == "45000002"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := samitest.NewServer()
+			defer s.Close()
+			s.Script(tt.session)
+
+			c := vc.New(s.Config())
+			defer c.Close()
+			getToken := 1
+			if tt.token != "" {
+				c.Init(tt.token, time.Now().Add(time.Hour).Unix())
+				getToken = 0
+			}
+
+			audio := make(chan []byte, 3)
+			audio <- []byte("ab")
+			audio <- []byte("cd")
+			audio <- []byte("ef")
+			close(audio)
+
+			var out bytes.Buffer
+			err := c.Conversion(context.Background(), vc.VoiceConversionRequest{Speaker: "speaker"}, audio, func(b []byte) { out.Write(b) })
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("Conversion() = %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Conversion() = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("audio = %q, want %q", out.String(), tt.want)
+			}
+
+			sessions := s.Sessions()
+			if len(sessions) != 1 {
+				t.Fatalf("server recorded %d sessions, want 1", len(sessions))
+			}
+			rec := sessions[0]
+			if rec.Frames != tt.frames || rec.Finished != tt.finished {
+				t.Errorf("session = %+v, want frames %d finished %v", rec, tt.frames, tt.finished)
+			}
+			if n := len(s.OpenApi.RequestsFor("GetToken")); n != getToken {
+				t.Errorf("GetToken called %d times, want %d", n, getToken)
+			}
+		})
+	}
+}
+
+func TestServerInvoke(t *testing.T) {
+	s := samitest.NewServer()
+	defer s.Close()
+	s.Script(samitest.Session{Transform: bytes.ToUpper})
+
+	cfg := s.Config()
+	c := sami.NewClient(cfg.Config, cfg.AppKey, nil)
+	defer c.Close()
+	c.Endpoint = cfg.SamiEndpoint
+
+	rsp, err := c.Invoke(context.Background(), sami.InvokeRequest{Namespace: "AudioSeparation", Version: "v4", Payload: map[string]int{"n": 1}, Data: []byte("audio")})
+	if err != nil {
+		t.Fatalf("Invoke() = %v", err)
+	}
+	if string(rsp.Data) != "AUDIO" || rsp.Payload != `{"n":1}` {
+		t.Errorf("response = %+v", rsp)
+	}
+
+	rec := s.Sessions()[0]
+	if rec.Start.Namespace != "AudioSeparation" || rec.Start.Version != "v4" || rec.Start.Token != samitest.Token {
+		t.Errorf("request = %+v", rec.Start)
+	}
+
+	s.Script(samitest.Session{StartFailure: &samitest.Failure{StatusCode: samitest.StatusInternalError, StatusText: "boom"}})
+	if _, err = c.Invoke(context.Background(), sami.InvokeRequest{Namespace: "AudioSeparation"}); err == nil {
+		t.Error("Invoke() = nil, want error")
+	}
+	// 默认不重试非幂等的任务
+	if n := len(s.Sessions()); n != 2 {
+		t.Errorf("server recorded %d requests, want 2", n)
+	}
+}
+
+func TestServerInvokeInvalidToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		getToken string // 不为空时GetToken返回该token
+		wantErr  bool
+	}{
+		{name: "refreshed"},
+		{name: "still invalid", getToken: "revoked", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := samitest.NewServer()
+			defer s.Close()
+			if tt.getToken != "" {
+				s.OpenApi.Respond("GetToken", "2021-07-27", sami.GetTokenResponse{StatusCode: 20000000, Token: tt.getToken, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+			}
+
+			cfg := s.Config()
+			c := sami.NewClient(cfg.Config, cfg.AppKey, nil)
+			defer c.Close()
+			c.Endpoint = cfg.SamiEndpoint
+			// 缓存的token已被服务端吊销
+			c.Tokens.Set(cfg.AppKey, "revoked", time.Now().Add(time.Hour).Unix())
+
+			var bodies []string
+			c.HTTPClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(b))
+				r.Body = io.NopCloser(bytes.NewReader(b))
+				return http.DefaultTransport.RoundTrip(r)
+			})}
+
+			_, err := c.Invoke(context.Background(), sami.InvokeRequest{Namespace: "AudioSeparation", Data: []byte("audio")})
+			if tt.wantErr {
+				var e *volcano.Error
+				if !errors.As(err, &e) || e.Code != "45000002" {
+					t.Fatalf("Invoke() = %v, want invalid token", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Invoke() = %v", err)
+			}
+
+			// 丢弃无效的token后重新获取，只重试一次
+			sessions := s.Sessions()
+			if len(sessions) != 2 {
+				t.Fatalf("server recorded %d requests, want 2", len(sessions))
+			}
+			if sessions[0].Start.Token != "revoked" {
+				t.Errorf("first request token = %q", sessions[0].Start.Token)
+			}
+			if n := len(s.OpenApi.RequestsFor("GetToken")); n != 1 {
+				t.Errorf("GetToken called %d times, want 1", n)
+			}
+
+			// token只通过query传递
+			for _, b := range bodies {
+				if strings.Contains(b, "token") || strings.Contains(b, "appkey") {
+					t.Errorf("request body = %s, want payload and data only", b)
+				}
+			}
+		})
+	}
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestServerReleasesStream(t *testing.T) {
+	s := samitest.NewServer()
+	defer s.Close()
+
+	cfg := s.Config()
+	cfg.Limiter = volcano.NewLimiter(volcano.Rate{}, nil, 1)
+	c := vc.New(cfg)
+	defer c.Close()
+
+	// 会话失败、正常结束后都释放会话数，否则后续会话会一直等待
+	for _, session := range []samitest.Session{
+		{StartFailure: &samitest.Failure{StatusCode: samitest.StatusInvalidRequest, StatusText: "bad speaker"}},
+		{FailAfter: 1},
+		{},
+		{},
+	} {
+		s.Script(session)
+
+		audio := make(chan []byte, 1)
+		audio <- []byte("ab")
+		close(audio)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := c.Conversion(ctx, vc.VoiceConversionRequest{Speaker: "speaker"}, audio, func([]byte) {})
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Conversion() = %v, stream slot not released", err)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package sami
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/jyinz/volcano-sdk"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// _Endpoint 默认的SAMI websocket地址
+var _Endpoint = volcano.Endpoint{
+	Scheme: "wss",
+	Host:   "sami.bytedance.com",
+}
+
+// Client SAMI任务客户端，可用于任意namespace：Start开启websocket会话，Invoke调用非流式任务
+type Client struct {
+	AppKey string
+	// Endpoint SAMI地址，未配置的字段使用默认值 wss://sami.bytedance.com
+	Endpoint volcano.Endpoint
+	// Tokens 获取token
+	Tokens *TokenManager
+
+	// Dialer 为空时使用websocket.DefaultDialer
+	Dialer *websocket.Dialer
+	// HTTPClient Invoke使用，为空时使用http.DefaultClient
+	HTTPClient *http.Client
+	// Retry Invoke使用的重试策略，默认不重试；任务非幂等且按次计费，重试可能导致重复计费，
+	// 需要时显式设置，cfg.Retry只用于GetToken
+	Retry volcano.RetryPolicy
+	// Limiter 按 sami/<namespace> 限流并限制会话数，为空时不限流
+	Limiter *volcano.Limiter
+	// Instrumenter 为空时不记录
+	Instrumenter volcano.Instrumenter
+	// Logger 为空时不输出日志
+	Logger *slog.Logger
+
+	// ownsTokens Tokens由NewClient创建，Close时一并关闭
+	ownsTokens bool
+}
+
+// NewClient 生成Client，tokens为空时使用cfg创建私有的TokenManager，Close时关闭；
+// 传入的tokens由调用方管理
+func NewClient(cfg volcano.Config, appKey string, tokens *TokenManager) *Client {
+	owns := tokens == nil
+	if owns {
+		tokens = NewTokenManager(NewOpenApi(cfg))
+	}
+
+	return &Client{
+		AppKey:       appKey,
+		Tokens:       tokens,
+		Dialer:       cfg.Dialer,
+		HTTPClient:   cfg.HTTPClient,
+		Retry:        volcano.NoRetry,
+		Limiter:      cfg.Limiter,
+		Instrumenter: cfg.Instrumenter,
+		Logger:       cfg.Logger,
+		ownsTokens:   owns,
+	}
+}
+
+// Close 关闭NewClient创建的TokenManager并停止后台刷新，调用方传入的TokenManager不受影响
+func (c *Client) Close() {
+	if c.ownsTokens {
+		c.Tokens.Close()
+	}
+}
+
+// Task 开启任务的参数
+type Task struct {
+	Namespace string
+	Version   string
+	// Payload 任务配置，string、[]byte原样发送，其他类型序列化为json
+	Payload any
+}
+
+// Start 开启任务，收到TaskStarted后返回会话；会话结束后需调用Close
+func (c *Client) Start(ctx context.Context, task Task) (_ *Session, err error) {
+	payload, err := encodePayload(task.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload failed: %w", err)
+	}
+
+	// 获取token
+	token, err := c.Tokens.Token(ctx, c.AppKey)
+	if err != nil {
+		return nil, fmt.Errorf("get token failed: %w", err)
+	}
+
+	err = c.Limiter.Wait(ctx, _Service, task.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// 会话占用直至Close
+	release, err := c.Limiter.AcquireStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
+
+	// 会话观测直至Close
+	ctx, span := volcano.StartSpan(ctx, c.Instrumenter, volcano.Operation{Kind: volcano.KindWebSocket, Service: _Service, Action: task.Namespace})
+	var status int
+	defer func() {
+		if err != nil {
+			span.End(status, err)
+		}
+	}()
+
+	u := c.Endpoint.Resolve(_Endpoint).URL("/api/v1/ws")
+
+	log := volcano.NewLogger(c.Logger).With("service", _Service, "action", task.Namespace)
+	log.DebugContext(ctx, "volcano sami session start", "url", u.String())
+	defer func() {
+		if err != nil {
+			log.DebugContext(ctx, "volcano sami session end", "status", status, "error", err)
+		}
+	}()
+
+	conn, rsp, err := c.websocketDialer().DialContext(ctx, u.String(), http.Header{})
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) {
+			defer rsp.Body.Close()
+			b, _ := io.ReadAll(rsp.Body)
+			status = rsp.StatusCode
+			return nil, fmt.Errorf("%w: %w", err, &volcano.Error{
+				StatusCode: rsp.StatusCode,
+				Service:    _Service,
+				Action:     task.Namespace,
+				Message:    string(b),
+			})
+		}
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	msg, _ := json.Marshal(WebSocketRequest{
+		Token:     token,
+		Appkey:    c.AppKey,
+		Namespace: task.Namespace,
+		Version:   task.Version,
+		Event:     EventStartTask,
+		Payload:   payload,
+	})
+	err = conn.WriteMessage(websocket.TextMessage, msg)
+	if err != nil {
+		return nil, fmt.Errorf("send config failed: %w", err)
+	}
+	span.AddBytes(len(msg), 0)
+
+	// 等待第一个回包，校验服务端是否完成配置
+	var wsRsp WebSocketResponse
+	err = conn.ReadJSON(&wsRsp)
+	if err != nil {
+		return nil, fmt.Errorf("read first event failed: %w", err)
+	}
+	status = int(wsRsp.StatusCode)
+
+	if err = wsRsp.Err(); err != nil {
+		return nil, err
+	}
+
+	if !wsRsp.Started() {
+		return nil, fmt.Errorf("fisrt event mismatched(%s), code=%d, msg=%s", wsRsp.Event, wsRsp.StatusCode, wsRsp.StatusText)
+	}
+	log = log.With("task_id", wsRsp.TaskId)
+	log.DebugContext(ctx, "volcano sami session started")
+
+	return &Session{
+		conn:      conn,
+		appKey:    c.AppKey,
+		token:     token,
+		namespace: task.Namespace,
+		taskId:    wsRsp.TaskId,
+		release:   release,
+		span:      span,
+		log:       log,
+		start:     time.Now(),
+		status:    status,
+	}, nil
+}
+
+func (c *Client) websocketDialer() *websocket.Dialer {
+	if c.Dialer != nil {
+		return c.Dialer
+	}
+	return websocket.DefaultDialer
+}
+
+// Message 会话收到的消息，二进制帧时Response为nil
+type Message struct {
+	Data     []byte
+	Response *WebSocketResponse
+}
+
+// Binary 是否为二进制帧
+func (m Message) Binary() bool {
+	return m.Response == nil
+}
+
+// Decode 将事件的payload解析到v
+func (m Message) Decode(v any) error {
+	if m.Response == nil || m.Response.Payload == "" {
+		return errors.New("message has no payload")
+	}
+	return json.Unmarshal([]byte(m.Response.Payload), v)
+}
+
+// Session SAMI任务会话：Send、SendRequest、Finish可并发调用，Recv需在同一goroutine中调用
+type Session struct {
+	conn      *websocket.Conn
+	appKey    string
+	token     string
+	namespace string
+	taskId    string
+
+	wmu sync.Mutex
+
+	// 释放会话占用
+	release func()
+
+	span  volcano.Span
+	log   *slog.Logger
+	start time.Time
+
+	mu       sync.Mutex
+	status   int
+	err      error // 会话中第一个错误，结束观测时上报
+	first    bool  // 是否已收到数据
+	finished bool
+	closed   bool
+}
+
+// TaskId 服务端返回的任务id
+func (s *Session) TaskId() string {
+	return s.taskId
+}
+
+// Send 发送二进制帧
+func (s *Session) Send(data []byte) error {
+	if err := s.write(websocket.BinaryMessage, data); err != nil {
+		return s.fail(fmt.Errorf("send data failed: %w", err))
+	}
+	return nil
+}
+
+// SendRequest 发送TaskRequest事件，payload同Task.Payload
+func (s *Session) SendRequest(payload any, data []byte) error {
+	pld, err := encodePayload(payload)
+	if err != nil {
+		return fmt.Errorf("encode payload failed: %w", err)
+	}
+	return s.sendEvent(EventTaskRequest, pld, data)
+}
+
+// Finish 发送FinishTask，服务端处理完成后返回TaskFinished
+func (s *Session) Finish() error {
+	return s.sendEvent(EventFinishTask, "", nil)
+}
+
+func (s *Session) sendEvent(event, payload string, data []byte) error {
+	msg, _ := json.Marshal(WebSocketRequest{
+		Token:     s.token,
+		Appkey:    s.appKey,
+		Namespace: s.namespace,
+		Event:     event,
+		Payload:   payload,
+		Data:      data,
+		TaskId:    s.taskId,
+	})
+	if err := s.write(websocket.TextMessage, msg); err != nil {
+		return s.fail(fmt.Errorf("send %s failed: %w", event, err))
+	}
+	return nil
+}
+
+func (s *Session) write(mt int, b []byte) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+
+	if err := s.conn.WriteMessage(mt, b); err != nil {
+		return err
+	}
+	s.span.AddBytes(len(b), 0)
+	return nil
+}
+
+// Recv 接收下一个二进制帧或事件；收到TaskFinished后返回io.EOF，收到TaskFailed时返回*volcano.Error；
+// ctx结束时关闭连接并返回ctx的错误
+func (s *Session) Recv(ctx context.Context) (Message, error) {
+	s.mu.Lock()
+	finished := s.finished
+	s.mu.Unlock()
+	if finished {
+		return Message{}, io.EOF
+	}
+
+	stop := context.AfterFunc(ctx, func() { _ = s.conn.Close() })
+	defer stop()
+
+	mt, msg, err := s.conn.ReadMessage()
+	if err != nil {
+		if ctx.Err() != nil {
+			return Message{}, s.fail(context.Cause(ctx))
+		}
+		return Message{}, s.fail(fmt.Errorf("recv failed: %w", err))
+	}
+	s.span.AddBytes(0, len(msg))
+
+	if mt == websocket.BinaryMessage {
+		s.firstChunk()
+		return Message{Data: msg}, nil
+	}
+
+	var wsRsp WebSocketResponse
+	if err = json.Unmarshal(msg, &wsRsp); err != nil {
+		return Message{}, s.fail(fmt.Errorf("parse data failed: %w", err))
+	}
+
+	s.mu.Lock()
+	s.status = int(wsRsp.StatusCode)
+	s.finished = wsRsp.Finished()
+	s.mu.Unlock()
+
+	if err = wsRsp.Err(); err != nil {
+		return Message{}, s.fail(err)
+	}
+	if wsRsp.Data != nil {
+		s.firstChunk()
+	}
+	if wsRsp.Finished() && wsRsp.Data == nil && wsRsp.Payload == "" {
+		return Message{}, io.EOF
+	}
+	return Message{Data: wsRsp.Data, Response: &wsRsp}, nil
+}
+
+// Close 关闭连接并结束观测，会话未收到TaskFinished时以第一个错误或context.Canceled结束
+func (s *Session) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	status, err := s.status, s.err
+	if err == nil && !s.finished {
+		err = context.Canceled
+	}
+	s.mu.Unlock()
+
+	cerr := s.conn.Close()
+	s.release()
+	s.span.End(status, err)
+	s.log.Debug("volcano sami session end", "status", status, "elapsed", time.Since(s.start), "error", err)
+	return cerr
+}
+
+// fail 记录会话中的第一个错误
+func (s *Session) fail(err error) error {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	return err
+}
+
+func (s *Session) firstChunk() {
+	s.mu.Lock()
+	first := !s.first
+	s.first = true
+	s.mu.Unlock()
+
+	if first {
+		s.span.FirstChunk()
+	}
+}
+
+func encodePayload(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+
+	b, err := json.Marshal(v)
+	return string(b), err
+}
@@ -1,13 +1,13 @@
 package sami
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/jyinz/volcano-sdk"
-	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -17,10 +17,11 @@ const (
 	_Region  = "cn-north-1"
 	_Version = "2021-07-27"
 	_Service = "sami"
-	_Path    = "/"
 
 	_TokenVersion = "volc-auth-v1"
 	_ResponseOK   = 20000000
+	// token无效或已过期
+	_ResponseInvalidToken = 45000002
 )
 
 type (
@@ -41,91 +42,155 @@ type (
 	}
 )
 
+// Token GetToken客户端，并保存最近一次获取的token；多个客户端共享或并发使用时使用TokenManager
 type Token struct {
 	volcano.OpenApi
 
+	// Cache token的共享缓存，按AppKey、access key和有效期在调用GetToken前查询，为空时不缓存
+	Cache TokenCache
+
+	mu        sync.RWMutex
 	token     string
 	expiresAt int64
 }
 
 // Expired returns true when token is expired.
 func (tkn *Token) Expired() bool {
+	tkn.mu.RLock()
+	defer tkn.mu.RUnlock()
+
 	// 提前1min判断过期，保证token使用过程时有效
-	return tkn.expiresAt == 0 || time.Now().Unix() > tkn.expiresAt-60
+	return tkn.expiresAt == 0 || time.Now().Unix() > tkn.expiresAt-int64(_ExpirySafety/time.Second)
 }
 
 // Refresh 刷新token
 func (tkn *Token) Refresh(ctx context.Context, appKey string, expire int64) error {
+	t, err := tkn.fetch(ctx, appKey, expire, _ExpirySafety, "")
+	if err != nil {
+		return err
+	}
+
+	tkn.Init(t.Token, t.ExpiresAt)
+	return nil
+}
+
+// fetch 获取token，配置了Cache时优先使用缓存中有效期超过validFor且不为rejected的token，
+// 缓存未命中时加锁后调用GetToken并写入缓存；缓存出错时直接调用GetToken
+func (tkn *Token) fetch(ctx context.Context, appKey string, expire int64, validFor time.Duration, rejected string) (CachedToken, error) {
+	log := volcano.NewLogger(tkn.Logger).With("appkey", appKey)
+	if tkn.Cache == nil {
+		return tkn.getToken(ctx, appKey, expire, log)
+	}
+
+	credentials, err := tkn.CurrentCredentials(ctx)
+	if err != nil {
+		return CachedToken{}, err
+	}
+	key := TokenCacheKey(appKey, credentials.AccessKeyID, expire)
+
+	if t, ok := tkn.cached(ctx, key, validFor, rejected, log); ok {
+		return t, nil
+	}
+
+	if locker, ok := tkn.Cache.(TokenLocker); ok {
+		unlock, err := locker.Lock(ctx, key)
+		if err != nil {
+			if ctx.Err() != nil {
+				return CachedToken{}, err
+			}
+			log.WarnContext(ctx, "volcano sami token cache lock failed", "error", err)
+		} else {
+			defer unlock()
+
+			// 等待锁期间其他进程可能已刷新
+			if t, ok := tkn.cached(ctx, key, validFor, rejected, log); ok {
+				return t, nil
+			}
+		}
+	}
+
+	t, err := tkn.getToken(ctx, appKey, expire, log)
+	if err != nil {
+		return CachedToken{}, err
+	}
+	if err = tkn.Cache.Set(ctx, key, t); err != nil {
+		log.WarnContext(ctx, "volcano sami token cache set failed", "error", err)
+	}
+	return t, nil
+}
+
+func (tkn *Token) cached(ctx context.Context, key string, validFor time.Duration, rejected string, log *slog.Logger) (CachedToken, bool) {
+	t, err := tkn.Cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrCacheMiss) {
+			log.WarnContext(ctx, "volcano sami token cache get failed", "error", err)
+		}
+		return CachedToken{}, false
+	}
+	// 已被服务端判定无效的token不再使用
+	if !t.ValidFor(validFor) || (rejected != "" && t.Token == rejected) {
+		return CachedToken{}, false
+	}
+
+	log.DebugContext(ctx, "volcano sami token loaded from cache", "token", t.Token, "expires_at", time.Unix(t.ExpiresAt, 0))
+	return t, true
+}
+
+func (tkn *Token) getToken(ctx context.Context, appKey string, expire int64, log *slog.Logger) (CachedToken, error) {
 	rsp, err := tkn.GetToken(ctx, GetTokenRequest{
 		AppKey:     appKey,
 		Expiration: expire,
 	})
 	if err != nil {
-		return err
+		return CachedToken{}, err
 	}
 
-	tkn.token = rsp.Token
-	tkn.expiresAt = rsp.ExpiresAt
+	log.DebugContext(ctx, "volcano sami token refreshed",
+		"token", rsp.Token, "expires_at", time.Unix(rsp.ExpiresAt, 0), "task_id", rsp.TaskId)
 
-	return nil
+	return CachedToken{Token: rsp.Token, ExpiresAt: rsp.ExpiresAt}, nil
 }
 
 // Token 获取当前token
 func (tkn *Token) Token() string {
+	tkn.mu.RLock()
+	defer tkn.mu.RUnlock()
+
 	return tkn.token
 }
 
 // Init 初始化一个token，一般用于测试
 func (tkn *Token) Init(token string, expiredAt int64) {
+	tkn.mu.Lock()
+	defer tkn.mu.Unlock()
+
 	tkn.token = token
 	tkn.expiresAt = expiredAt
 }
 
 func (tkn *Token) GetToken(ctx context.Context, gr GetTokenRequest) (*GetTokenResponse, error) {
-	u := tkn.BuildUrl(_Path, _Version, "GetToken")
-
 	gr.TokenVersion = _TokenVersion
 
-	return tkn.post(ctx, u.String(), gr)
-}
-
-func (tkn *Token) post(ctx context.Context, url string, body any) (*GetTokenResponse, error) {
-	b, _ := json.Marshal(body)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
-	if err != nil {
-		return nil, fmt.Errorf("bad request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	return do(tkn.Sign(req))
-}
-
-func do(req *http.Request) (*GetTokenResponse, error) {
-	rsp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("do request failed: %w", err)
-	}
-
-	defer rsp.Body.Close()
-
-	rb, err := io.ReadAll(rsp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
 	var ret = new(GetTokenResponse)
-	err = json.Unmarshal(rb, ret)
+	err := tkn.Invoke(ctx, "GetToken", _Version, gr, ret)
 	if err != nil {
-		return nil, fmt.Errorf("parse data failed: %w", err)
+		return nil, err
 	}
 
-	fmt.Println(string(rb))
-
 	// 请求失败，解析错误原因
 	if ret.StatusCode != _ResponseOK {
-		return nil, fmt.Errorf("response error(%s): code = %d, desc = %s", rsp.Status, ret.Code, ret.Msg)
+		msg := ret.StatusText
+		if ret.Msg != "" {
+			msg = ret.Msg
+		}
+		return nil, &volcano.Error{
+			StatusCode: http.StatusOK,
+			Service:    _Service,
+			Action:     "GetToken",
+			Code:       strconv.Itoa(int(ret.StatusCode)),
+			Message:    msg,
+			RequestId:  ret.TaskId,
+		}
 	}
 
 	return ret, nil
@@ -133,13 +198,6 @@ func do(req *http.Request) (*GetTokenResponse, error) {
 
 func NewOpenApi(cfg volcano.Config) *Token {
 	return &Token{
-		OpenApi: volcano.OpenApi{
-			Credentials: volcano.Credentials{
-				AccessKeyID:     cfg.AccessKey,
-				SecretAccessKey: cfg.SecretKey,
-				Service:         _Service,
-				Region:          _Region,
-			},
-		},
+		OpenApi: volcano.NewOpenApi(cfg, _Service, _Region),
 	}
 }
@@ -1,5 +1,10 @@
 package sami
 
+import (
+	"github.com/jyinz/volcano-sdk"
+	"strconv"
+)
+
 const (
 	EventStartTask    = "StartTask"
 	EventTaskStarted  = "TaskStarted"
@@ -43,3 +48,22 @@ func (rsp WebSocketResponse) Started() bool {
 func (rsp WebSocketResponse) Finished() bool {
 	return rsp.Event == EventTaskFinished
 }
+
+func (rsp WebSocketResponse) Failed() bool {
+	return rsp.Event == EventTaskFailed
+}
+
+// Err 返回包为TaskFailed或状态码不为成功时返回*volcano.Error
+func (rsp WebSocketResponse) Err() error {
+	if !rsp.Failed() && (rsp.StatusCode == 0 || rsp.StatusCode == _ResponseOK) {
+		return nil
+	}
+
+	return &volcano.Error{
+		Service:   _Service,
+		Action:    rsp.Namespace,
+		Code:      strconv.Itoa(int(rsp.StatusCode)),
+		Message:   rsp.StatusText,
+		RequestId: rsp.TaskId,
+	}
+}
@@ -2,19 +2,14 @@ package vc
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/gorilla/websocket"
 	"github.com/jyinz/volcano-sdk"
 	"github.com/jyinz/volcano-sdk/sami"
 	"io"
-	"net/http"
-	"net/url"
 )
 
 const (
-	_Host      = "sami.bytedance.com"
 	_Namespace = "VoiceConversionStream"
 )
 
@@ -37,24 +32,10 @@ type (
 	}
 )
 
-func (vcr VoiceConversionRequest) wsMsg(ak, tkn string) []byte {
-	pld, _ := json.Marshal(vcr)
-
-	var wsMsg = sami.WebSocketRequest{
-		Token:     tkn,
-		Appkey:    ak,
-		Namespace: _Namespace,
-		Event:     sami.EventStartTask,
-		Payload:   string(pld),
-	}
-
-	b, _ := json.Marshal(wsMsg)
-	return b
-}
-
 type VoiceConversion struct {
-	appKey string
-	*sami.Token
+	client *sami.Client
+	// ownsTokens TokenManager由New创建，Close时一并关闭
+	ownsTokens bool
 }
 
 // Conversion 对输入音频进行音色转换
@@ -69,56 +50,43 @@ func (c *VoiceConversion) Conversion(ctx context.Context, vcr VoiceConversionReq
 
 // CreateSpeaker 生成一个Speaker用于进行音色转换，提前生成Speaker可以降低延迟
 func (c *VoiceConversion) CreateSpeaker(ctx context.Context, vcr VoiceConversionRequest) (Speaker, error) {
-	// 获取token
-	if c.Expired() {
-		err := c.Refresh(ctx, c.appKey, 12*3600)
-		if err != nil {
-			return nil, fmt.Errorf("get token failed: %w", err)
-		}
-	}
-	token := c.Token.Token()
-
-	u := url.URL{Scheme: "wss", Host: _Host, Path: "/api/v1/ws"}
-	conn, rsp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), http.Header{})
+	sess, err := c.client.Start(ctx, sami.Task{Namespace: _Namespace, Payload: vcr})
 	if err != nil {
-		if errors.Is(err, websocket.ErrBadHandshake) {
-			defer rsp.Body.Close()
-			b, _ := io.ReadAll(rsp.Body)
-			return nil, fmt.Errorf("%w, reason:%s", err, string(b))
-		}
 		return nil, err
 	}
-	defer conn.Close()
+	return &speaker{s: sess}, nil
+}
 
-	err = conn.WriteMessage(websocket.TextMessage, vcr.wsMsg(c.appKey, token))
-	if err != nil {
-		return nil, fmt.Errorf("send config failed: %w", err)
-	}
+// Token 获取当前appkey的token，过期时刷新
+func (c *VoiceConversion) Token(ctx context.Context) (string, error) {
+	return c.client.Tokens.Token(ctx, c.client.AppKey)
+}
 
-	// 等待第一个回包，校验服务端是否完成配置,TODO: 返回包错误处理
-	//mt, msg, err := conn.ReadMessage()
-	var wsRsp sami.WebSocketResponse
-	err = conn.ReadJSON(&wsRsp)
-	if err != nil {
-		return nil, fmt.Errorf("read first event failed: %w", err)
-	}
+// Refresh 立即刷新当前appkey的token
+func (c *VoiceConversion) Refresh(ctx context.Context) (string, error) {
+	return c.client.Tokens.Refresh(ctx, c.client.AppKey)
+}
 
-	if !wsRsp.Started() {
-		return nil, fmt.Errorf("fisrt event mismatched(%s), code=%d, msg=%s", wsRsp.Event, wsRsp.StatusCode, wsRsp.StatusText)
-	}
+// Init 初始化一个token，一般用于测试
+func (c *VoiceConversion) Init(token string, expiredAt int64) {
+	c.client.Tokens.Set(c.client.AppKey, token, expiredAt)
+}
+
+// TokenManager 返回获取token使用的TokenManager
+func (c *VoiceConversion) TokenManager() *sami.TokenManager {
+	return c.client.Tokens
+}
 
-	fnsMsg, _ := json.Marshal(sami.WebSocketRequest{
-		Token:     token,    // 尾包可传空
-		Appkey:    c.appKey, // 尾包可传空
-		Namespace: _Namespace,
-		Event:     sami.EventFinishTask,
-	})
-
-	return &speaker{
-		ctx:    ctx,
-		c:      conn,
-		fnsMsg: fnsMsg,
-	}, nil
+// Client 返回底层的SAMI任务客户端
+func (c *VoiceConversion) Client() *sami.Client {
+	return c.client
+}
+
+// Close 关闭New创建的TokenManager并停止后台刷新，Config.Tokens传入的TokenManager由调用方关闭
+func (c *VoiceConversion) Close() {
+	if c.ownsTokens {
+		c.client.Tokens.Close()
+	}
 }
 
 type (
@@ -127,22 +95,20 @@ type (
 	}
 
 	speaker struct {
-		ctx context.Context
-		c   *websocket.Conn
-
-		// 尾包数据
-		fnsMsg []byte
+		s *sami.Session
 	}
 )
 
 func (s *speaker) Speak(ctx context.Context, chunks <-chan []byte, cb func([]byte)) error {
+	defer s.s.Close()
+
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(nil)
 
 	go func() {
 		defer func() {
 			// 数据发送完毕时发送尾包
-			_ = s.c.WriteMessage(websocket.TextMessage, s.fnsMsg)
+			_ = s.s.Finish()
 
 			// 释放chunks避免前序阻塞
 			for range chunks {
@@ -150,58 +116,58 @@ func (s *speaker) Speak(ctx context.Context, chunks <-chan []byte, cb func([]byt
 		}()
 
 		for chunk := range chunks {
-			err := s.c.WriteMessage(websocket.BinaryMessage, chunk)
-			if err != nil {
-				cancel(fmt.Errorf("send data failed :%w", err))
+			if err := s.s.Send(chunk); err != nil {
+				cancel(err)
 				return
 			}
 		}
-
 	}()
 
 	// 同步接收返回
 	for {
-		mt, msg, err := s.c.ReadMessage()
-		if err != nil {
-			cancel(fmt.Errorf("recv failed: %w", err))
+		msg, err := s.s.Recv(ctx)
+		if errors.Is(err, io.EOF) {
 			return context.Cause(ctx)
 		}
-
-		if mt == websocket.BinaryMessage {
-			cb(msg)
-			continue
-		}
-
-		var wsRsp sami.WebSocketResponse
-		err = json.Unmarshal(msg, &wsRsp)
 		if err != nil {
-			cancel(fmt.Errorf("parse data failed: %w", err))
+			cancel(err)
 			return context.Cause(ctx)
 		}
 
-		// TODO: 返回包错误处理
-		_ = wsRsp.StatusCode
-
-		if wsRsp.Data != nil {
-			cb(wsRsp.Data)
-		}
-
-		if wsRsp.Finished() {
-			break
+		if msg.Data != nil {
+			cb(msg.Data)
 		}
 	}
-
-	return context.Cause(ctx)
 }
 
 type Config struct {
-	volcano.Config
-	AppKey string `json:"app_key" yaml:"app_key"`
+	volcano.Config `yaml:",inline"`
+	AppKey         string `json:"app_key" yaml:"app_key"`
+
+	// SamiEndpoint SAMI地址，默认为 wss://sami.bytedance.com
+	SamiEndpoint volcano.Endpoint `json:"sami_endpoint" yaml:"sami_endpoint"`
+
+	// Tokens 共享的TokenManager，为空时每个客户端各自管理token
+	Tokens *sami.TokenManager `json:"-" yaml:"-"`
+	// TokenCache 多个进程共享的token缓存，为空时不缓存；配置Tokens时使用Tokens自身的缓存
+	TokenCache sami.TokenCache `json:"-" yaml:"-"`
 }
 
+// New 生成音色转换客户端，未配置Tokens时创建私有的TokenManager，不再使用时需调用Close
 func New(cfg Config) *VoiceConversion {
+	owns := cfg.Tokens == nil
+	tokens := cfg.Tokens
+	if owns {
+		token := sami.NewOpenApi(cfg.Config)
+		token.Cache = cfg.TokenCache
+		tokens = sami.NewTokenManager(token)
+	}
+
+	client := sami.NewClient(cfg.Config, cfg.AppKey, tokens)
+	client.Endpoint = cfg.SamiEndpoint
+
 	return &VoiceConversion{
-		appKey: cfg.AppKey,
-		Token:  sami.NewOpenApi(cfg.Config),
+		client:     client,
+		ownsTokens: owns,
 	}
 }
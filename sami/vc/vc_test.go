@@ -0,0 +1,88 @@
+package vc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jyinz/volcano-sdk/sami"
+	"github.com/jyinz/volcano-sdk/sami/samitest"
+	"github.com/jyinz/volcano-sdk/sami/vc"
+	"testing"
+)
+
+func TestConversion(t *testing.T) {
+	s := samitest.NewServer()
+	defer s.Close()
+	s.Script(samitest.Session{Transform: bytes.ToUpper})
+
+	c := vc.New(s.Config())
+	defer c.Close()
+
+	vcr := vc.VoiceConversionRequest{
+		Speaker:     "speaker",
+		AudioInfo:   vc.AudioInfo{SampleRate: 16000, Channel: 1, Format: "s16le"},
+		AudioConfig: vc.AudioInfo{SampleRate: 24000, Channel: 1, Format: "s16le"},
+		Extra:       &vc.Extra{DownstreamAlign: true},
+	}
+	audio := make(chan []byte, 2)
+	audio <- []byte("ab")
+	audio <- []byte("cd")
+	close(audio)
+
+	var out bytes.Buffer
+	if err := c.Conversion(context.Background(), vcr, audio, func(b []byte) { out.Write(b) }); err != nil {
+		t.Fatalf("Conversion() = %v", err)
+	}
+	if out.String() != "ABCD" {
+		t.Errorf("audio = %q, want %q", out.String(), "ABCD")
+	}
+
+	// 音色转换请求作为任务的payload发送
+	rec := s.Sessions()[0]
+	want, _ := json.Marshal(vcr)
+	if rec.Start.Namespace != "VoiceConversionStream" || rec.Start.Payload != string(want) {
+		t.Errorf("start = %+v, want payload %s", rec.Start, want)
+	}
+	if rec.Start.Token != samitest.Token || rec.Start.Appkey != samitest.AppKey {
+		t.Errorf("start token = %q, appkey = %q", rec.Start.Token, rec.Start.Appkey)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	s := samitest.NewServer()
+	defer s.Close()
+	ctx := context.Background()
+
+	// 共享的TokenManager只获取一次token，客户端Close时不关闭
+	cfg := s.Config()
+	cfg.Tokens = sami.NewTokenManager(sami.NewOpenApi(cfg.Config))
+	defer cfg.Tokens.Close()
+
+	c1, c2 := vc.New(cfg), vc.New(cfg)
+	for _, c := range []*vc.VoiceConversion{c1, c2} {
+		if tkn, err := c.Token(ctx); err != nil || tkn != samitest.Token {
+			t.Fatalf("Token() = %q, %v", tkn, err)
+		}
+	}
+	if n := len(s.OpenApi.RequestsFor("GetToken")); n != 1 {
+		t.Errorf("GetToken called %d times, want 1", n)
+	}
+	c1.Close()
+	if _, err := c2.Token(ctx); err != nil {
+		t.Errorf("Token() after closing another client = %v", err)
+	}
+
+	// 私有的TokenManager随客户端关闭
+	c3 := vc.New(s.Config())
+	if c3.TokenManager() == cfg.Tokens {
+		t.Fatal("client without Tokens shares the TokenManager")
+	}
+	if _, err := c3.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() = %v", err)
+	}
+	c3.Close()
+	if _, err := c3.Token(ctx); !errors.Is(err, sami.ErrManagerClosed) {
+		t.Errorf("Token() after Close = %v, want ErrManagerClosed", err)
+	}
+}
@@ -0,0 +1,137 @@
+package sami
+
+import (
+	"encoding/json"
+	"github.com/jyinz/volcano-sdk"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+)
+
+const (
+	// DefaultVendingExpiration TokenHandler默认签发的token有效期，单位秒
+	DefaultVendingExpiration = 3600
+
+	// TokenHandler限流使用的service，action为调用方标识
+	_VendingService = "sami-token"
+)
+
+// VendedToken TokenHandler返回的token
+type VendedToken struct {
+	AppKey    string `json:"appkey"`
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// TokenHandler 为浏览器、移动端等不能持有AK/SK的调用方签发SAMI token，
+// 请求参数appkey通过query或表单传递，返回VendedToken；同一AppKey的token由Tokens缓存并在过期前刷新
+type TokenHandler struct {
+	// Tokens 获取token，签发的有效期为Tokens.Expiration
+	Tokens *TokenManager
+	// AppKeys 允许签发token的AppKey，为空时拒绝所有请求
+	AppKeys []string
+	// Authorize 校验调用方，返回调用方标识用于限流，返回错误时拒绝请求；为空时拒绝所有请求
+	Authorize func(r *http.Request, appKey string) (subject string, err error)
+	// Limiter 按调用方限流，key为 sami-token/<调用方标识>，使用Default或按标识配置的Rates；为空时不限流
+	Limiter *volcano.Limiter
+	// Logger 为空时不输出日志
+	Logger *slog.Logger
+}
+
+// NewTokenHandler 使用api获取appKeys的token，签发的token有效期为DefaultVendingExpiration；
+// authorize校验调用方，不需要校验时需显式传入AllowAll
+func NewTokenHandler(api *Token, authorize func(r *http.Request, appKey string) (string, error), appKeys ...string) *TokenHandler {
+	tokens := NewTokenManager(api)
+	tokens.Expiration = DefaultVendingExpiration
+
+	return &TokenHandler{
+		Tokens:    tokens,
+		AppKeys:   appKeys,
+		Authorize: authorize,
+		Logger:    api.Logger,
+	}
+}
+
+// AllowAll 不校验调用方，使用客户端IP作为限流的标识；仅用于已由网关等完成鉴权的部署
+func AllowAll(r *http.Request, _ string) (string, error) {
+	return clientIP(r), nil
+}
+
+func (h *TokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	log := volcano.NewLogger(h.Logger)
+
+	appKey := r.FormValue("appkey")
+	if appKey == "" {
+		writeError(w, http.StatusBadRequest, "missing appkey")
+		return
+	}
+
+	// 先校验调用方，避免未授权的调用方探测允许的AppKey
+	if h.Authorize == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	subject, err := h.Authorize(r, appKey)
+	if err != nil {
+		log.DebugContext(ctx, "volcano sami token request unauthorized", "appkey", appKey, "error", err)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !slices.Contains(h.AppKeys, appKey) {
+		writeError(w, http.StatusForbidden, "appkey not allowed")
+		return
+	}
+
+	if !h.Limiter.Allow(_VendingService, subject) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "too many requests")
+		return
+	}
+
+	t, err := h.Tokens.Get(ctx, appKey)
+	if err != nil {
+		// 不向调用方暴露GetToken的错误详情
+		log.WarnContext(ctx, "volcano sami token vending failed", "appkey", appKey, "subject", subject, "error", err)
+		writeError(w, http.StatusBadGateway, "get token failed")
+		return
+	}
+	log.DebugContext(ctx, "volcano sami token vended", "appkey", appKey, "subject", subject)
+
+	writeJSON(w, http.StatusOK, VendedToken{
+		AppKey:    appKey,
+		Token:     t.Token,
+		ExpiresAt: t.ExpiresAt,
+	})
+}
+
+// Close 停止Tokens的后台刷新
+func (h *TokenHandler) Close() {
+	h.Tokens.Close()
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,91 @@
+package sami
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/volcanotest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenHandler(t *testing.T) {
+	s := volcanotest.NewServer()
+	defer s.Close()
+	s.Handle("GetToken", _Version, func(r *volcanotest.Request) *volcanotest.Response {
+		var gr GetTokenRequest
+		_ = r.Decode(&gr)
+		return &volcanotest.Response{Result: GetTokenResponse{
+			StatusCode: _ResponseOK,
+			Token:      "token-" + gr.AppKey,
+			ExpiresAt:  time.Now().Unix() + gr.Expiration,
+		}}
+	})
+
+	authorize := func(r *http.Request, _ string) (string, error) {
+		if user := r.Header.Get("X-User"); user != "" {
+			return user, nil
+		}
+		return "", errors.New("no user")
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		query     string
+		user      string
+		authorize func(r *http.Request, appKey string) (string, error)
+		status    int
+	}{
+		{name: "ok", query: "appkey=app", user: "alice", authorize: authorize, status: http.StatusOK},
+		{name: "allow all", query: "appkey=app", authorize: AllowAll, status: http.StatusOK},
+		{name: "method not allowed", method: http.MethodDelete, query: "appkey=app", user: "alice", authorize: authorize, status: http.StatusMethodNotAllowed},
+		{name: "missing appkey", user: "alice", authorize: authorize, status: http.StatusBadRequest},
+		{name: "no authorize", query: "appkey=app", user: "alice", status: http.StatusUnauthorized},
+		{name: "unauthorized", query: "appkey=app", authorize: authorize, status: http.StatusUnauthorized},
+		// 未授权的调用方不能区分AppKey是否允许
+		{name: "unauthorized unknown appkey", query: "appkey=other", authorize: authorize, status: http.StatusUnauthorized},
+		{name: "appkey not allowed", query: "appkey=other", user: "alice", authorize: authorize, status: http.StatusForbidden},
+		{name: "throttled", query: "appkey=app", user: "bob", authorize: authorize, status: http.StatusTooManyRequests},
+	}
+
+	limiter := volcano.NewLimiter(volcano.Rate{QPS: 1}, nil, 0)
+	// bob的令牌已用完
+	limiter.Allow(_VendingService, "bob")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTokenHandler(NewOpenApi(s.Config()), tt.authorize, "app")
+			defer h.Close()
+			h.Limiter = limiter
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/token?"+tt.query, nil)
+			if tt.user != "" {
+				r.Header.Set("X-User", tt.user)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var vt VendedToken
+			if err := json.Unmarshal(w.Body.Bytes(), &vt); err != nil {
+				t.Fatal(err)
+			}
+			if vt.Token != "token-app" || vt.ExpiresAt > time.Now().Unix()+DefaultVendingExpiration {
+				t.Errorf("token = %+v", vt)
+			}
+		})
+	}
+}
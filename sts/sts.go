@@ -0,0 +1,124 @@
+package sts
+
+import (
+	"context"
+	"fmt"
+	"github.com/jyinz/volcano-sdk"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// open api info
+const (
+	_Service = "sts"
+	_Region  = "cn-north-1"
+	_Version = "2018-01-01"
+
+	// 临时凭证在过期前5min刷新
+	_ExpiryWindow = 5 * time.Minute
+	// 未配置HTTPClient时的请求超时时间，避免刷新凭证时一直阻塞
+	_Timeout = 10 * time.Second
+)
+
+type (
+	AssumeRoleRequest struct {
+		RoleTrn         string // 角色的TRN，格式为 trn:iam::${AccountId}:role/${RoleName}
+		RoleSessionName string // 角色会话名称，用于区分不同的调用方
+		DurationSeconds int    // 临时凭证有效期，单位秒，[900, 43200]，默认为3600
+		Policy          string // 会话策略，用于进一步限制临时凭证的权限，可选
+	}
+)
+
+type (
+	Credentials struct {
+		CurrentTime     string `json:"CurrentTime"`
+		ExpiredTime     string `json:"ExpiredTime"`
+		AccessKeyId     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		SessionToken    string `json:"SessionToken"`
+	}
+
+	AssumedRoleUser struct {
+		Trn           string `json:"Trn"`
+		AssumedRoleId string `json:"AssumedRoleId"`
+	}
+
+	AssumeRoleResult struct {
+		Credentials     Credentials     `json:"Credentials"`
+		AssumedRoleUser AssumedRoleUser `json:"AssumedRoleUser"`
+	}
+)
+
+// Expiration 临时凭证的过期时间
+func (c Credentials) Expiration() (time.Time, error) {
+	return time.Parse(time.RFC3339, c.ExpiredTime)
+}
+
+type STS struct {
+	volcano.OpenApi
+}
+
+// AssumeRole 扮演角色，获取临时凭证
+func (c *STS) AssumeRole(ctx context.Context, ar AssumeRoleRequest) (*AssumeRoleResult, error) {
+	query := url.Values{
+		"RoleTrn":         []string{ar.RoleTrn},
+		"RoleSessionName": []string{ar.RoleSessionName},
+	}
+	if ar.DurationSeconds > 0 {
+		query.Set("DurationSeconds", strconv.Itoa(ar.DurationSeconds))
+	}
+	if ar.Policy != "" {
+		query.Set("Policy", ar.Policy)
+	}
+
+	var ret = new(AssumeRoleResult)
+	err := c.Invoke(ctx, "AssumeRole", _Version, query, ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// New 生成STS客户端，未配置HTTPClient时使用超时为10s的client
+func New(cfg volcano.Config) *STS {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: _Timeout}
+	}
+	return &STS{
+		volcano.NewOpenApi(cfg, _Service, _Region),
+	}
+}
+
+// Provider 通过AssumeRole获取临时凭证的凭证来源
+type Provider struct {
+	STS     *STS
+	Request AssumeRoleRequest
+}
+
+func (p *Provider) Retrieve(ctx context.Context) (volcano.CredentialsValue, error) {
+	ret, err := p.STS.AssumeRole(ctx, p.Request)
+	if err != nil {
+		return volcano.CredentialsValue{}, fmt.Errorf("assume role failed: %w", err)
+	}
+
+	expires, err := ret.Credentials.Expiration()
+	if err != nil {
+		return volcano.CredentialsValue{}, fmt.Errorf("parse expired time failed: %w", err)
+	}
+
+	return volcano.CredentialsValue{
+		AccessKeyID:     ret.Credentials.AccessKeyId,
+		SecretAccessKey: ret.Credentials.SecretAccessKey,
+		SessionToken:    ret.Credentials.SessionToken,
+		Expires:         expires,
+		Source:          "sts:" + p.Request.RoleTrn,
+	}, nil
+}
+
+// NewProvider 返回自动刷新的临时凭证来源，可作为volcano.Config.Provider供其他服务使用
+func NewProvider(cli *STS, ar AssumeRoleRequest) volcano.CredentialsProvider {
+	return volcano.NewCredentialsCache(&Provider{STS: cli, Request: ar}, _ExpiryWindow)
+}
@@ -0,0 +1,168 @@
+package sts
+
+import (
+	"context"
+	"errors"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/volcanotest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testRoleTrn = "trn:iam::2100000000:role/test"
+
+// newTestServer AssumeRole返回expiresIn后过期的临时凭证
+func newTestServer(expiresIn time.Duration) *volcanotest.Server {
+	s := volcanotest.NewServer()
+	s.Handle("AssumeRole", _Version, func(r *volcanotest.Request) *volcanotest.Response {
+		if r.Query.Get("RoleTrn") != testRoleTrn {
+			return &volcanotest.Response{Error: &volcano.ResponseError{Code: "InvalidParameter.RoleTrn", Message: "invalid role"}}
+		}
+
+		now := time.Now()
+		return &volcanotest.Response{Result: AssumeRoleResult{
+			Credentials: Credentials{
+				CurrentTime:     now.Format(time.RFC3339),
+				ExpiredTime:     now.Add(expiresIn).Format(time.RFC3339),
+				AccessKeyId:     "AKLTsts",
+				SecretAccessKey: "sts-secret",
+				SessionToken:    "sts-session",
+			},
+			AssumedRoleUser: AssumedRoleUser{Trn: testRoleTrn + "/" + r.Query.Get("RoleSessionName"), AssumedRoleId: "role-id"},
+		}}
+	})
+	return s
+}
+
+func TestAssumeRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		request AssumeRoleRequest
+		query   map[string]string
+		wantErr func(err error) bool
+	}{
+		{
+			name:    "ok",
+			request: AssumeRoleRequest{RoleTrn: testRoleTrn, RoleSessionName: "session"},
+			query:   map[string]string{"RoleTrn": testRoleTrn, "RoleSessionName": "session", "DurationSeconds": "", "Policy": ""},
+		},
+		{
+			name:    "duration and policy",
+			request: AssumeRoleRequest{RoleTrn: testRoleTrn, RoleSessionName: "session", DurationSeconds: 900, Policy: `{"Statement":[]}`},
+			query:   map[string]string{"DurationSeconds": "900", "Policy": `{"Statement":[]}`},
+		},
+		{
+			name:    "invalid role",
+			request: AssumeRoleRequest{RoleTrn: "trn:iam::1:role/other", RoleSessionName: "session"},
+			wantErr: volcano.IsInvalidParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(time.Hour)
+			defer s.Close()
+
+			ret, err := New(s.Config()).AssumeRole(context.Background(), tt.request)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("AssumeRole() = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AssumeRole() = %v", err)
+			}
+			if ret.Credentials.AccessKeyId != "AKLTsts" || ret.AssumedRoleUser.Trn != testRoleTrn+"/session" {
+				t.Errorf("result = %+v", ret)
+			}
+
+			r := s.Requests()[0]
+			if r.SignatureErr != nil || r.Service != _Service || r.Region != _Region || r.Method != http.MethodGet {
+				t.Errorf("request = %+v", r)
+			}
+			for k, v := range tt.query {
+				if got := r.Query.Get(k); got != v {
+					t.Errorf("query %s = %q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		calls     int
+	}{
+		{name: "cached", expiresIn: time.Hour, calls: 1},
+		// 在过期前_ExpiryWindow内每次都重新获取
+		{name: "within expiry window", expiresIn: _ExpiryWindow - time.Minute, calls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(tt.expiresIn)
+			defer s.Close()
+
+			p := NewProvider(New(s.Config()), AssumeRoleRequest{RoleTrn: testRoleTrn, RoleSessionName: "session"})
+			for range 2 {
+				v, err := p.Retrieve(context.Background())
+				if err != nil {
+					t.Fatalf("Retrieve() = %v", err)
+				}
+				if v.AccessKeyID != "AKLTsts" || v.SecretAccessKey != "sts-secret" || v.SessionToken != "sts-session" || v.Source != "sts:"+testRoleTrn {
+					t.Errorf("credentials = %+v", v)
+				}
+				if d := time.Until(v.Expires); d <= 0 || d > tt.expiresIn {
+					t.Errorf("expires in %v, want within %v", d, tt.expiresIn)
+				}
+			}
+			if n := len(s.RequestsFor("AssumeRole")); n != tt.calls {
+				t.Errorf("AssumeRole called %d times, want %d", n, tt.calls)
+			}
+		})
+	}
+}
+
+func TestProviderSignsWithTemporaryCredentials(t *testing.T) {
+	s := newTestServer(time.Hour)
+	defer s.Close()
+	s.AddCredentials("AKLTsts", "sts-secret", "sts-session")
+	s.Respond("ListUsers", "2018-01-01", map[string]int{"Total": 1})
+
+	cfg := s.Config()
+	cfg.Provider = NewProvider(New(s.Config()), AssumeRoleRequest{RoleTrn: testRoleTrn, RoleSessionName: "session"})
+	cfg.AccessKey, cfg.SecretKey = "", ""
+
+	c := volcano.NewOpenApi(cfg, "iam", "cn-north-1")
+	if err := c.Invoke(context.Background(), "ListUsers", "2018-01-01", nil, nil); err != nil {
+		t.Fatalf("Invoke() = %v", err)
+	}
+	r := s.RequestsFor("ListUsers")[0]
+	if r.AccessKey != "AKLTsts" || r.Header.Get("X-Security-Token") != "sts-session" {
+		t.Errorf("request = %+v", r)
+	}
+}
+
+func TestNewDefaultTimeout(t *testing.T) {
+	c := New(volcano.Config{AccessKey: "ak", SecretKey: "sk"})
+	if c.HTTPClient == nil || c.HTTPClient.Timeout != _Timeout {
+		t.Errorf("HTTPClient = %+v, want timeout %v", c.HTTPClient, _Timeout)
+	}
+
+	// 请求超时时返回错误而不是一直阻塞
+	s := volcanotest.NewServer()
+	defer s.Close()
+	s.Enqueue("AssumeRole", _Version, &volcanotest.Response{Delay: time.Second})
+
+	cfg := s.Config()
+	cfg.HTTPClient = &http.Client{Timeout: 50 * time.Millisecond}
+	_, err := New(cfg).AssumeRole(context.Background(), AssumeRoleRequest{RoleTrn: testRoleTrn})
+	var ne interface{ Timeout() bool }
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("AssumeRole() = %v, want timeout", err)
+	}
+}
@@ -0,0 +1,370 @@
+// Package volcanotest 提供本地的OpenApi模拟服务，用于在测试中替代 open.volcengineapi.com
+//
+//	srv := volcanotest.NewServer()
+//	defer srv.Close()
+//
+//	srv.Respond("GetToken", "2021-07-27", sami.GetTokenResponse{StatusCode: 20000000, Token: "token"})
+//	tkn := sami.NewOpenApi(srv.Config())
+package volcanotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jyinz/volcano-sdk"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 模拟服务默认的密钥
+const (
+	AccessKey = "AKLTvolcanotest"
+	SecretKey = "volcanotest-secret"
+)
+
+// Request 模拟服务收到的请求
+type Request struct {
+	Action    string
+	Version   string
+	Service   string // 签名范围中的服务
+	Region    string // 签名范围中的地域
+	AccessKey string
+
+	Method string
+	Header http.Header
+	Query  url.Values
+	Body   []byte
+
+	// SignatureErr 验签失败的原因，验签失败的请求不会被路由；
+	// 签名值不匹配时可通过errors.As获取*volcano.SignatureError，将Expected与客户端的SignatureDebug对比
+	SignatureErr error
+}
+
+// Decode 将请求体解析到v中
+func (r *Request) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Response 模拟服务的返回
+type Response struct {
+	StatusCode int // 为0时，Error为空返回200，否则返回400
+	Result     any // 序列化为Result
+	Error      *volcano.ResponseError
+	RequestId  string // 为空时自动生成
+
+	// Delay 返回前等待的时间，请求取消时提前结束
+	Delay time.Duration
+	// Drop 为true时不返回任何数据直接断开连接
+	Drop bool
+}
+
+// HandlerFunc 根据请求生成返回
+type HandlerFunc func(r *Request) *Response
+
+// Server 模拟的OpenApi服务：校验签名、按Action/Version路由并记录收到的请求
+type Server struct {
+	*httptest.Server
+
+	// Now 模拟服务的当前时间，用于验签和Date header，为空时使用本地时间；可用于模拟客户端时间偏差
+	Now func() time.Time
+
+	mu          sync.Mutex
+	credentials map[string]volcano.Credentials
+	handlers    map[route]HandlerFunc
+	queues      map[route][]*Response
+	requests    []*Request
+
+	seq atomic.Int64
+}
+
+type route struct {
+	action, version string
+}
+
+// NewServer 启动模拟服务，默认接受 AccessKey/SecretKey 签名的请求
+func NewServer() *Server {
+	s := &Server{
+		credentials: make(map[string]volcano.Credentials),
+		handlers:    make(map[route]HandlerFunc),
+		queues:      make(map[route][]*Response),
+	}
+	s.AddCredentials(AccessKey, SecretKey, "")
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddCredentials 添加可通过验签的密钥，sessionToken不为空时请求需携带相同的X-Security-Token
+func (s *Server) AddCredentials(accessKey, secretKey, sessionToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.credentials[accessKey] = volcano.Credentials{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		SessionToken:    sessionToken,
+	}
+}
+
+// Endpoint 模拟服务的地址
+func (s *Server) Endpoint() volcano.Endpoint {
+	u, _ := url.Parse(s.URL)
+	return volcano.Endpoint{Host: u.Host, Scheme: u.Scheme}
+}
+
+// Config 使用默认密钥访问模拟服务的配置，不重试
+func (s *Server) Config() volcano.Config {
+	return volcano.Config{
+		AccessKey:  AccessKey,
+		SecretKey:  SecretKey,
+		Endpoint:   s.Endpoint(),
+		HTTPClient: s.Client(),
+		Retry:      volcano.NoRetry,
+	}
+}
+
+// Handle 设置action的处理函数，version为空时匹配所有版本
+func (s *Server) Handle(action, version string, h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[route{action, version}] = h
+}
+
+// Respond action固定返回result
+func (s *Server) Respond(action, version string, result any) {
+	s.Handle(action, version, func(*Request) *Response {
+		return &Response{Result: result}
+	})
+}
+
+// RespondError action固定返回错误
+func (s *Server) RespondError(action, version string, statusCode int, code, message string) {
+	s.Handle(action, version, func(*Request) *Response {
+		return &Response{StatusCode: statusCode, Error: &volcano.ResponseError{Code: code, Message: message}}
+	})
+}
+
+// Enqueue 添加一次性的返回，按顺序优先于Handle设置的处理函数使用，用于模拟重试等场景
+func (s *Server) Enqueue(action, version string, rsps ...*Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := route{action, version}
+	s.queues[r] = append(s.queues[r], rsps...)
+}
+
+// Requests 返回收到的所有请求，包括验签失败的请求
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Request(nil), s.requests...)
+}
+
+// RequestsFor 返回指定action收到的请求
+func (s *Server) RequestsFor(action string) []*Request {
+	var ret []*Request
+	for _, r := range s.Requests() {
+		if r.Action == action {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+// Reset 清空处理函数、一次性返回以及记录的请求
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers = make(map[route]HandlerFunc)
+	s.queues = make(map[route][]*Response)
+	s.requests = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	query := r.URL.Query()
+	req := &Request{
+		Action:  query.Get("Action"),
+		Version: query.Get("Version"),
+		Method:  r.Method,
+		Header:  r.Header.Clone(),
+		Query:   query,
+		Body:    body,
+	}
+	req.AccessKey, req.Region, req.Service = parseScope(r)
+	req.SignatureErr = s.verifier(req).Verify(r)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	var rsp *Response
+	switch {
+	case req.SignatureErr != nil:
+		code := "SignatureDoesNotMatch"
+		var se *volcano.SignatureError
+		if errors.As(req.SignatureErr, &se) && se.Part == volcano.SignaturePartDate {
+			code = "InvalidTimestamp"
+		}
+		rsp = &Response{
+			StatusCode: http.StatusUnauthorized,
+			Error:      &volcano.ResponseError{Code: code, Message: req.SignatureErr.Error()},
+		}
+	case req.Action == "" || req.Version == "":
+		rsp = &Response{
+			StatusCode: http.StatusBadRequest,
+			Error:      &volcano.ResponseError{Code: "MissingRequestInfo", Message: "Action or Version is missing"},
+		}
+	default:
+		rsp = s.route(req)
+	}
+
+	s.write(w, r, req, rsp)
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// verifier 签名范围中的服务和地域由请求决定，只校验密钥和签名
+func (s *Server) verifier(req *Request) *volcano.Verifier {
+	return &volcano.Verifier{
+		Service: req.Service,
+		Region:  req.Region,
+		Now:     s.now,
+		Lookup: func(accessKeyID string) (volcano.Credentials, bool) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			c, ok := s.credentials[accessKeyID]
+			return c, ok
+		},
+	}
+}
+
+func (s *Server) route(req *Request) *Response {
+	s.mu.Lock()
+	var (
+		h   HandlerFunc
+		rsp *Response
+	)
+	for _, r := range []route{{req.Action, req.Version}, {req.Action, ""}} {
+		if q := s.queues[r]; len(q) > 0 {
+			rsp, s.queues[r] = q[0], q[1:]
+			break
+		}
+	}
+	if rsp == nil {
+		h = s.handlers[route{req.Action, req.Version}]
+		if h == nil {
+			h = s.handlers[route{req.Action, ""}]
+		}
+	}
+	s.mu.Unlock()
+
+	if rsp != nil {
+		return rsp
+	}
+	if h != nil {
+		if rsp = h(req); rsp != nil {
+			return rsp
+		}
+		return &Response{}
+	}
+	return &Response{
+		StatusCode: http.StatusNotFound,
+		Error: &volcano.ResponseError{
+			Code:    "InvalidActionOrVersion",
+			Message: fmt.Sprintf("Could not find operation %s for version %s", req.Action, req.Version),
+		},
+	}
+}
+
+func (s *Server) write(w http.ResponseWriter, r *http.Request, req *Request, rsp *Response) {
+	if rsp.Delay > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(rsp.Delay):
+		}
+	}
+
+	if rsp.Drop {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				_ = conn.Close()
+				return
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+
+	requestId := rsp.RequestId
+	if requestId == "" {
+		requestId = fmt.Sprintf("volcanotest-%d", s.seq.Add(1))
+	}
+
+	statusCode := rsp.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+		if rsp.Error != nil {
+			statusCode = http.StatusBadRequest
+		}
+	}
+
+	var ret struct {
+		ResponseMetadata volcano.ResponseMetadata `json:"ResponseMetadata"`
+		Result           any                      `json:"Result,omitempty"`
+	}
+	ret.ResponseMetadata = volcano.ResponseMetadata{
+		RequestId: requestId,
+		Action:    req.Action,
+		Version:   req.Version,
+		Service:   req.Service,
+		Region:    req.Region,
+		Error:     rsp.Error,
+	}
+	ret.Result = rsp.Result
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Date", s.now().Format(http.TimeFormat))
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(ret)
+}
+
+// parseScope 从Authorization或X-Credential中解析 AccessKeyID/Date/Region/Service/request
+func parseScope(r *http.Request) (accessKey, region, service string) {
+	credential := r.URL.Query().Get("X-Credential")
+	if auth := r.Header.Get("Authorization"); credential == "" && auth != "" {
+		_, params, _ := strings.Cut(auth, " ")
+		for _, kv := range strings.Split(params, ",") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(kv), "Credential="); ok {
+				credential = v
+			}
+		}
+	}
+
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 {
+		return "", "", ""
+	}
+	return parts[0], parts[2], parts[3]
+}
@@ -0,0 +1,135 @@
+package volcanotest_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jyinz/volcano-sdk"
+	"github.com/jyinz/volcano-sdk/volcanotest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type listUsersResult struct {
+	Total int
+}
+
+func TestServer(t *testing.T) {
+	retry := &volcano.Backoff{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	tests := []struct {
+		name     string
+		setup    func(s *volcanotest.Server, cfg *volcano.Config)
+		wantErr  func(err error) bool
+		wantCall int
+	}{
+		{
+			name:     "ok",
+			wantCall: 1,
+		},
+		{
+			name:     "wrong secret key",
+			setup:    func(s *volcanotest.Server, cfg *volcano.Config) { cfg.SecretKey = "wrong" },
+			wantErr:  func(err error) bool { return errors.Is(err, volcano.ErrAuthFailure) },
+			wantCall: 1,
+		},
+		{
+			name: "session token",
+			setup: func(s *volcanotest.Server, cfg *volcano.Config) {
+				s.AddCredentials("AKLTsts", "sts-secret", "session")
+				cfg.AccessKey, cfg.SecretKey = "", ""
+				cfg.Provider = volcano.NewStaticProvider("AKLTsts", "sts-secret", "session")
+			},
+			wantCall: 1,
+		},
+		{
+			name:     "unknown action",
+			setup:    func(s *volcanotest.Server, cfg *volcano.Config) { s.Reset() },
+			wantErr:  func(err error) bool { return errors.Is(err, volcano.ErrInvalidParameter) },
+			wantCall: 1,
+		},
+		{
+			name: "retry after throttled",
+			setup: func(s *volcanotest.Server, cfg *volcano.Config) {
+				cfg.Retry = retry
+				s.Enqueue("ListUsers", "2018-01-01", &volcanotest.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Error:      &volcano.ResponseError{Code: "FlowLimitExceeded", Message: "throttled"},
+				})
+			},
+			wantCall: 2,
+		},
+		{
+			name: "retry after dropped connection",
+			setup: func(s *volcanotest.Server, cfg *volcano.Config) {
+				cfg.Retry = retry
+				s.Enqueue("ListUsers", "2018-01-01", &volcanotest.Response{Drop: true})
+			},
+			wantCall: 2,
+		},
+		{
+			name: "clock skew corrected",
+			setup: func(s *volcanotest.Server, cfg *volcano.Config) {
+				s.Now = func() time.Time { return time.Now().Add(-40 * time.Minute) }
+			},
+			wantCall: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := volcanotest.NewServer()
+			defer s.Close()
+			s.Respond("ListUsers", "2018-01-01", listUsersResult{Total: 3})
+
+			cfg := s.Config()
+			if tt.setup != nil {
+				tt.setup(s, &cfg)
+			}
+
+			c := volcano.NewOpenApi(cfg, "iam", "cn-north-1")
+			var ret listUsersResult
+			err := c.Invoke(context.Background(), "ListUsers", "2018-01-01", nil, &ret)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("Invoke() = %v", err)
+				}
+			} else if err != nil || ret.Total != 3 {
+				t.Fatalf("Invoke() = %v, result %+v", err, ret)
+			}
+
+			if n := len(s.RequestsFor("ListUsers")); n != tt.wantCall {
+				t.Errorf("server received %d requests, want %d", n, tt.wantCall)
+			}
+		})
+	}
+}
+
+func TestServerRecordsRequest(t *testing.T) {
+	s := volcanotest.NewServer()
+	defer s.Close()
+
+	s.Handle("CreateUser", "", func(r *volcanotest.Request) *volcanotest.Response {
+		var body struct{ UserName string }
+		if err := r.Decode(&body); err != nil {
+			return &volcanotest.Response{Error: &volcano.ResponseError{Code: "InvalidParameter", Message: err.Error()}}
+		}
+		return &volcanotest.Response{Result: body}
+	})
+
+	c := volcano.NewOpenApi(s.Config(), "iam", "cn-north-1")
+	var ret struct{ UserName string }
+	err := c.Invoke(context.Background(), "CreateUser", "2021-08-01", map[string]string{"UserName": "alice"}, &ret)
+	if err != nil || ret.UserName != "alice" {
+		t.Fatalf("Invoke() = %v, result %+v", err, ret)
+	}
+
+	reqs := s.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("server received %d requests, want 1", len(reqs))
+	}
+	r := reqs[0]
+	if r.Service != "iam" || r.Region != "cn-north-1" || r.AccessKey != volcanotest.AccessKey || r.SignatureErr != nil {
+		t.Errorf("request = %+v", r)
+	}
+}